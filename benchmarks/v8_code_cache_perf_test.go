@@ -0,0 +1,103 @@
+package benchmarks
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mtrempoltsev/gojs/engines"
+	"github.com/mtrempoltsev/gojs/engines/v8"
+)
+
+func makeBundle() string {
+	var code strings.Builder
+
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&code,
+			"function f%d(x) { var r = []; for (var i = 0; i < x; ++i) { r.push({ i: i, s: 'v' + i }); } return r.length + %d; }\n",
+			i, i)
+	}
+
+	code.WriteString("f0(1)")
+
+	return code.String()
+}
+
+func newBenchRunner(engine engines.Engine) engines.Runner {
+	runner, err := engine.NewRunner(engines.RunnerOptions{})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return runner
+}
+
+// benchmarkColdCompile compiles the bundle by a fresh runner on every
+// iteration, so the compilation cache of the isolate is always empty as it
+// is after a process restart
+func benchmarkColdCompile(b *testing.B, withCache bool) {
+	const id = "bundle.js"
+	code := makeBundle()
+
+	engine, err := v8.New(1)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	defer engine.Dispose()
+
+	var cache []byte
+
+	if withCache {
+		runner := newBenchRunner(engine)
+
+		script, err := runner.Compile(id, code)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		cache, err = script.CodeCache()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		script.Dispose()
+		runner.Dispose()
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		runner := newBenchRunner(engine)
+		b.StartTimer()
+
+		script, rejected, err := runner.CompileWithCache(id, code, cache)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if withCache && rejected {
+			fmt.Println("the code cache is rejected")
+			os.Exit(1)
+		}
+
+		b.StopTimer()
+		script.Dispose()
+		runner.Dispose()
+		b.StartTimer()
+	}
+}
+
+func BenchmarkV8CompileBundle(b *testing.B) {
+	benchmarkColdCompile(b, false)
+}
+
+func BenchmarkV8CompileBundleWithCache(b *testing.B) {
+	benchmarkColdCompile(b, true)
+}
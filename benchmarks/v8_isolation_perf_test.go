@@ -0,0 +1,47 @@
+package benchmarks
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/mtrempoltsev/gojs"
+)
+
+func benchmarkIsolation(b *testing.B, opts ...gojs.Option) {
+	const id = "isolation.js"
+	const code = "var x = { a: [1, 2, 3], s: 'ok', o: { x: 2, y: false } }; x.a.length"
+
+	js, err := gojs.New(1, opts...)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer js.Dispose()
+
+	err = js.Compile(id, code)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		res, err := js.Run(id)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		res.Dispose()
+	}
+}
+
+func BenchmarkV8RunSharedContext(b *testing.B) {
+	benchmarkIsolation(b)
+}
+
+func BenchmarkV8RunFreshContext(b *testing.B) {
+	benchmarkIsolation(b, gojs.WithIsolation())
+}
@@ -0,0 +1,416 @@
+package gojs
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+type ConsoleLevel string
+
+const (
+	ConsoleDebug ConsoleLevel = "debug"
+	ConsoleLog   ConsoleLevel = "log"
+	ConsoleInfo  ConsoleLevel = "info"
+	ConsoleWarn  ConsoleLevel = "warn"
+	ConsoleError ConsoleLevel = "error"
+	ConsoleTrace ConsoleLevel = "trace"
+)
+
+// ConsoleMessage is a line printed by a script through the console object
+type ConsoleMessage struct {
+	Level   ConsoleLevel
+	Message string
+	// Script is the name of the script that was running when the message
+	// was printed, Runner is the index of the runner that executed it
+	Script string
+	Runner int
+}
+
+type ConsoleHandler func(msg ConsoleMessage)
+
+func defaultConsoleHandler(msg ConsoleMessage) {
+	log.Printf("%s #%d %s: %s", msg.Script, msg.Runner, msg.Level, msg.Message)
+}
+
+// console implements the console object of a runner. Like eventLoop it is
+// used only from the goroutine of the runner
+type console struct {
+	runner  *runnerCtx
+	handler ConsoleHandler
+	timers  map[string]time.Time
+}
+
+func installConsole(runner *runnerCtx, handler ConsoleHandler) error {
+	if handler == nil {
+		handler = defaultConsoleHandler
+	}
+
+	c := &console{
+		runner:  runner,
+		handler: handler,
+		timers:  make(map[string]time.Time),
+	}
+
+	printer := func(level ConsoleLevel) func(args ...engines.Value) {
+		return func(args ...engines.Value) {
+			c.print(level, args)
+		}
+	}
+
+	funcs := []struct {
+		name string
+		fn   interface{}
+	}{
+		{"console.log", printer(ConsoleLog)},
+		{"console.info", printer(ConsoleInfo)},
+		{"console.warn", printer(ConsoleWarn)},
+		{"console.error", printer(ConsoleError)},
+		{"console.debug", printer(ConsoleDebug)},
+		{"console.trace", c.trace},
+		{"console.table", c.table},
+		{"console.time", c.time},
+		{"console.timeEnd", c.timeEnd},
+		{"console.assert", c.assert},
+	}
+
+	for _, f := range funcs {
+		err := runner.runner.RegisterFunc(f.name, f.fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func disposeValues(values []engines.Value) {
+	for _, val := range values {
+		if val != nil {
+			val.Dispose()
+		}
+	}
+}
+
+func formatValues(args []engines.Value) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = arg.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *console) emit(level ConsoleLevel, message string) {
+	c.handler(ConsoleMessage{
+		Level:   level,
+		Message: message,
+		Script:  c.runner.current,
+		Runner:  c.runner.index,
+	})
+}
+
+func (c *console) print(level ConsoleLevel, args []engines.Value) {
+	defer disposeValues(args)
+	c.emit(level, formatValues(args))
+}
+
+const stackName = "gojs:console"
+
+// stack returns the frames of the script that has called the console. Native
+// frames aren't listed, so the only extra frame is the one of the function
+// compiled here
+func (c *console) stack() string {
+	runner := c.runner.runner
+
+	fnValue, err := runner.CompileFunction(stackName, "return new Error().stack;", nil)
+	if err != nil {
+		return ""
+	}
+
+	defer fnValue.Dispose()
+
+	fn, err := fnValue.ToFunction()
+	if err != nil {
+		return ""
+	}
+
+	defer fn.Dispose()
+
+	res, err := fn.Call()
+	if err != nil {
+		return ""
+	}
+
+	defer res.Dispose()
+
+	stack, _ := res.ToString()
+
+	lines := strings.Split(stack, "\n")
+
+	frames := make([]string, 0, len(lines))
+	for _, line := range lines[1:] {
+		if !strings.Contains(line, stackName) {
+			frames = append(frames, line)
+		}
+	}
+
+	return strings.Join(frames, "\n")
+}
+
+func (c *console) trace(args ...engines.Value) {
+	defer disposeValues(args)
+
+	message := strings.TrimSpace("Trace: " + formatValues(args))
+	if stack := c.stack(); len(stack) > 0 {
+		message += "\n" + stack
+	}
+
+	c.emit(ConsoleTrace, message)
+}
+
+const tableName = "gojs:console.table"
+
+// tableRows is compiled to collect the cells of console.table, so the keys
+// keep the order of JavaScript. The first row is the header
+const tableRows = `
+var format = function (v) {
+  switch (typeof v) {
+  case 'string':
+    return JSON.stringify(v);
+  case 'bigint':
+    return v + 'n';
+  case 'function':
+    return '[Function' + (v.name ? ': ' + v.name : '') + ']';
+  case 'object':
+    if (v === null) {
+      return 'null';
+    }
+    try {
+      return JSON.stringify(v);
+    } catch (e) {
+      return String(v);
+    }
+  }
+  return String(v);
+};
+
+var keys = Object.keys(data);
+var cols = Array.isArray(columns) ? columns.map(String) : [];
+var values = false;
+
+keys.forEach(function (key) {
+  var row = data[key];
+  if (row !== null && typeof row === 'object') {
+    if (!Array.isArray(columns)) {
+      Object.keys(row).forEach(function (col) {
+        if (cols.indexOf(col) < 0) {
+          cols.push(col);
+        }
+      });
+    }
+  } else {
+    values = true;
+  }
+});
+
+var header = ['(index)'].concat(cols);
+if (values) {
+  header.push('Values');
+}
+
+return [header].concat(keys.map(function (key) {
+  var row = data[key];
+  var isObject = row !== null && typeof row === 'object';
+  var cells = [key].concat(cols.map(function (col) {
+    return isObject && Object.prototype.hasOwnProperty.call(row, col) ? format(row[col]) : '';
+  }));
+  if (values) {
+    cells.push(isObject ? '' : format(row));
+  }
+  return cells;
+}));
+`
+
+// rows returns the cells of the table, the first row is the header
+func (c *console) rows(data, columns engines.Value) ([][]string, error) {
+	runner := c.runner.runner
+
+	fnValue, err := runner.CompileFunction(tableName, tableRows, []string{"data", "columns"})
+	if err != nil {
+		return nil, err
+	}
+
+	defer fnValue.Dispose()
+
+	fn, err := fnValue.ToFunction()
+	if err != nil {
+		return nil, err
+	}
+
+	defer fn.Dispose()
+
+	args := []engines.Value{data}
+	if columns != nil {
+		args = append(args, columns)
+	}
+
+	res, err := fn.Call(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Dispose()
+
+	var rows [][]string
+	if err := res.ToObject(&rows); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// drawTable draws the rows in a box like Node.js does
+func drawTable(rows [][]string) string {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	border := func(left, middle, right string) string {
+		parts := make([]string, len(widths))
+		for i, width := range widths {
+			parts[i] = strings.Repeat("─", width+2)
+		}
+		return left + strings.Join(parts, middle) + right
+	}
+
+	line := func(row []string) string {
+		parts := make([]string, len(row))
+		for i, cell := range row {
+			parts[i] = " " + cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+1)
+		}
+		return "│" + strings.Join(parts, "│") + "│"
+	}
+
+	lines := []string{border("┌", "┬", "┐"), line(rows[0]), border("├", "┼", "┤")}
+	for _, row := range rows[1:] {
+		lines = append(lines, line(row))
+	}
+	lines = append(lines, border("└", "┴", "┘"))
+
+	return strings.Join(lines, "\n")
+}
+
+// table prints an array or an object as a table with a row for each element
+// and a column for each property of the elements. Other values are printed
+// like console.log does
+func (c *console) table(data engines.Value, columns engines.Value) {
+	if data != nil {
+		defer data.Dispose()
+	}
+	if columns != nil {
+		defer columns.Dispose()
+	}
+
+	if data == nil || !(data.IsObject() || data.IsArray()) {
+		var args []engines.Value
+		if data != nil {
+			args = append(args, data)
+		}
+		c.emit(ConsoleLog, formatValues(args))
+		return
+	}
+
+	rows, err := c.rows(data, columns)
+	if err != nil || len(rows) < 2 {
+		c.emit(ConsoleLog, data.String())
+		return
+	}
+
+	c.emit(ConsoleLog, drawTable(rows))
+}
+
+func label(val engines.Value) string {
+	if val == nil || val.IsUndefined() {
+		return "default"
+	}
+	return val.String()
+}
+
+func (c *console) time(val engines.Value) {
+	if val != nil {
+		defer val.Dispose()
+	}
+
+	name := label(val)
+
+	if _, ok := c.timers[name]; ok {
+		c.emit(ConsoleWarn, fmt.Sprintf("Timer '%s' already exists", name))
+		return
+	}
+
+	c.timers[name] = time.Now()
+}
+
+func (c *console) timeEnd(val engines.Value) {
+	if val != nil {
+		defer val.Dispose()
+	}
+
+	name := label(val)
+
+	start, ok := c.timers[name]
+	if !ok {
+		c.emit(ConsoleWarn, fmt.Sprintf("Timer '%s' does not exist", name))
+		return
+	}
+
+	delete(c.timers, name)
+
+	elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+	c.emit(ConsoleLog, fmt.Sprintf("%s: %.3fms", name, elapsed))
+}
+
+func truthy(val engines.Value) bool {
+	switch {
+	case val == nil || val.IsUndefined() || val.IsNull():
+		return false
+	case val.IsBoolean():
+		b, _ := val.ToBool()
+		return b
+	case val.IsNumber():
+		f, _ := val.ToFloat()
+		return f != 0 && !math.IsNaN(f)
+	case val.IsString():
+		s, _ := val.ToString()
+		return len(s) > 0
+	}
+	return true
+}
+
+func (c *console) assert(condition engines.Value, args ...engines.Value) {
+	if condition != nil {
+		defer condition.Dispose()
+	}
+	defer disposeValues(args)
+
+	if truthy(condition) {
+		return
+	}
+
+	message := "Assertion failed"
+	if len(args) > 0 {
+		message += ": " + formatValues(args)
+	}
+
+	c.emit(ConsoleError, message)
+}
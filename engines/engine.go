@@ -1,5 +1,26 @@
 package engines
 
+import "errors"
+
+// ErrHeapLimit is returned when a script is terminated because its runner
+// has reached the heap limit. Such a runner must be disposed
+var ErrHeapLimit = errors.New("engines: heap limit reached")
+
+// RunnerOptions are the parameters of a new runner, zero values mean
+// the engine defaults
+type RunnerOptions struct {
+	MaxOldGenerationSize   int
+	MaxYoungGenerationSize int
+	// Snapshot is a startup snapshot created by Engine.BuildSnapshot
+	Snapshot []byte
+}
+
+// SnapshotScript is a script executed while a startup snapshot is built
+type SnapshotScript struct {
+	Name string
+	Code string
+}
+
 type Function interface {
 	Call(args ...Value) (Value, error)
 	Terminate()
@@ -10,15 +31,65 @@ type Script interface {
 	Run() (Value, error)
 	Terminate()
 	GetFunction(funcName string) (Function, error)
+	// CodeCache serializes the compiled code, the cache includes functions
+	// compiled lazily by previous runs
+	CodeCache() ([]byte, error)
+	// Reset moves the script to a new context, the globals changed by
+	// previous runs are lost and functions got by GetFunction must not be
+	// used anymore
+	Reset() error
 	Dispose()
 }
 
+// Context is a set of globals shared by the scripts compiled in it
+type Context interface {
+	Compile(id, code string) (Script, error)
+	// Dispose must be called after all scripts of the context are disposed
+	Dispose()
+}
+
+type ModuleLoader interface {
+	// Resolve returns the identifier and the source code of the module
+	// imported by specifier from the module with identifier referrer.
+	// Modules with the same identifier are loaded once per compiled module
+	Resolve(specifier, referrer string) (id, source string, err error)
+}
+
+type HeapStatistics struct {
+	TotalHeapSize  uint64
+	UsedHeapSize   uint64
+	HeapSizeLimit  uint64
+	ExternalMemory uint64
+}
+
 type Runner interface {
 	Compile(id, code string) (Script, error)
+	// CompileWithCache compiles code using a cache made by Script.CodeCache,
+	// a cache that doesn't match the code or the engine is rejected and
+	// the code is compiled from scratch
+	CompileWithCache(id, code string, cache []byte) (script Script, rejected bool, err error)
+	CompileModule(id, code string, loader ModuleLoader) (Script, error)
+	CompileFunction(name, code string, params []string) (Value, error)
+	NewContext() (Context, error)
+	NewValue(val interface{}) (Value, error)
+	RegisterFunc(name string, fn interface{}) error
+	RunMicrotasks()
+	EnqueueMicrotask(fn Value) error
+	Terminate()
+	CancelTerminate()
+	HeapLimitReached() bool
+	// IsDead reports that the isolate can't execute scripts anymore after
+	// a fatal error
+	IsDead() bool
+	HeapStatistics() HeapStatistics
 	Dispose()
 }
 
 type Engine interface {
-	NewRunner() (Runner, error)
+	NewRunner(options RunnerOptions) (Runner, error)
+	// BuildSnapshot runs scripts in a new context and serializes the
+	// resulting heap, runners created with the snapshot start with
+	// the globals defined by the scripts
+	BuildSnapshot(scripts []SnapshotScript) ([]byte, error)
 	Dispose()
 }
@@ -0,0 +1,69 @@
+package engines
+
+import (
+	"fmt"
+	"strings"
+)
+
+type StackFrame struct {
+	Function string `json:"function,omitempty"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// JSError describes an exception thrown by a script or a compilation error.
+// Lines and columns are 1-based, zero means unknown
+type JSError struct {
+	Name       string       `json:"name,omitempty"`
+	Message    string       `json:"message"`
+	File       string       `json:"file,omitempty"`
+	Line       int          `json:"line,omitempty"`
+	Column     int          `json:"column,omitempty"`
+	EndColumn  int          `json:"endColumn,omitempty"`
+	SourceLine string       `json:"sourceLine,omitempty"`
+	Stack      []StackFrame `json:"stack,omitempty"`
+}
+
+func (err *JSError) Error() string {
+	buf := strings.Builder{}
+
+	if len(err.File) > 0 {
+		fmt.Fprintf(&buf, "%s:%d: ", err.File, err.Line)
+	}
+
+	if len(err.Name) > 0 {
+		fmt.Fprintf(&buf, "%s: ", err.Name)
+	}
+
+	buf.WriteString(err.Message)
+
+	if len(err.SourceLine) > 0 {
+		fmt.Fprintf(&buf, "\n%s", err.SourceLine)
+
+		if err.Column > 0 {
+			width := err.EndColumn - err.Column
+			if width < 1 {
+				width = 1
+			}
+			fmt.Fprintf(&buf, "\n%s%s",
+				strings.Repeat(" ", err.Column-1),
+				strings.Repeat("^", width))
+		}
+	}
+
+	if len(err.Stack) > 0 {
+		buf.WriteString("\nstack trace:")
+		for _, frame := range err.Stack {
+			if len(frame.Function) > 0 {
+				fmt.Fprintf(&buf, "\n    at %s (%s:%d:%d)",
+					frame.Function, frame.File, frame.Line, frame.Column)
+			} else {
+				fmt.Fprintf(&buf, "\n    at %s:%d:%d",
+					frame.File, frame.Line, frame.Column)
+			}
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,177 @@
+package v8
+
+// #include <stdlib.h>
+// #include <v8capi.h>
+//
+// extern bool goCallback(uintptr_t data, struct v8_value* args, int argc, struct v8_value* result, char** exception);
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+	valueType = reflect.TypeOf((*engines.Value)(nil)).Elem()
+)
+
+type callback struct {
+	name    string
+	isolate *C.struct_v8_isolate
+	fn      reflect.Value
+}
+
+func checkCallback(fn reflect.Value) error {
+	if fn.Kind() != reflect.Func {
+		return fmt.Errorf("Can't register %q, a function is expected", fn.Type())
+	}
+
+	fnType := fn.Type()
+
+	switch fnType.NumOut() {
+	case 0:
+	case 1:
+	case 2:
+		if fnType.Out(1) != errorType {
+			return errors.New("The second result of a function must be an error")
+		}
+	default:
+		return errors.New("A function must return at most a value and an error")
+	}
+
+	return nil
+}
+
+// RegisterFunc installs fn as a global function. The name may be a dotted
+// path like "console.log", missing objects on the path are created
+func (runner *Runner) RegisterFunc(name string, fn interface{}) error {
+	value := reflect.ValueOf(fn)
+
+	err := checkCallback(value)
+	if err != nil {
+		return err
+	}
+
+	id := addHandle(&callback{
+		name:    name,
+		isolate: runner.ptr,
+		fn:      value,
+	})
+
+	namePtr := C.CString(name)
+	defer C.free(unsafe.Pointer(namePtr))
+
+	var cErr C.struct_v8_error
+	defer C.v8_delete_error(&cErr)
+
+	if !C.v8_register_function(runner.ptr, namePtr, C.v8_callback(C.goCallback), id, &cErr) {
+		removeHandle(id)
+		return makeError(cErr)
+	}
+
+	runner.mutex.Lock()
+	runner.handles = append(runner.handles, id)
+	runner.mutex.Unlock()
+
+	return nil
+}
+
+func toGoValue(data C.struct_v8_value, value reflect.Value) error {
+	return (&decoder{}).decode("", data, value)
+}
+
+func setException(exception **C.char, format string, args ...interface{}) C.bool {
+	*exception = C.CString(fmt.Sprintf(format, args...))
+	return false
+}
+
+//export goCallback
+func goCallback(data C.uintptr_t, args *C.struct_v8_value, argc C.int, result *C.struct_v8_value, exception **C.char) (ok C.bool) {
+	cb, _ := getHandle(data).(*callback)
+	if cb == nil {
+		return setException(exception, "Function is not registered")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			ok = setException(exception, "%s: %v", cb.name, r)
+		}
+	}()
+
+	fnType := cb.fn.Type()
+
+	numIn := fnType.NumIn()
+	if fnType.IsVariadic() {
+		numIn--
+	}
+
+	size := int(argc)
+	if size < numIn {
+		size = numIn
+	}
+
+	in := make([]reflect.Value, 0, size)
+
+	ptr := unsafe.Pointer(args)
+	elemSize := unsafe.Sizeof(*args)
+
+	for i := 0; i < size; i++ {
+		var argType reflect.Type
+		if i < numIn {
+			argType = fnType.In(i)
+		} else if fnType.IsVariadic() {
+			argType = fnType.In(numIn).Elem()
+		} else {
+			break
+		}
+
+		arg := reflect.New(argType).Elem()
+
+		// missing arguments are left zeroed as JavaScript does with undefined
+		if i < int(argc) {
+			err := toGoValue(*(*C.struct_v8_value)(ptr), arg)
+			if err != nil {
+				return setException(exception, "%s: argument %d: %s", cb.name, i, err)
+			}
+			ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+		}
+
+		in = append(in, arg)
+	}
+
+	out := cb.fn.Call(in)
+
+	if len(out) > 0 && fnType.Out(len(out)-1) == errorType {
+		if err := out[len(out)-1]; !err.IsNil() {
+			return setException(exception, "%s", err.Interface().(error).Error())
+		}
+		out = out[:len(out)-1]
+	}
+
+	if len(out) == 0 {
+		*result = C.v8_new_undefined(cb.isolate)
+		return true
+	}
+
+	// a returned engines.Value is passed to the engine without copying
+	if out[0].Kind() == reflect.Interface && !out[0].IsNil() {
+		if val, ok := out[0].Interface().(Value); ok {
+			*result = val.data
+			return true
+		}
+	}
+
+	val, err := newValue(cb.isolate, out[0], 0)
+	if err != nil {
+		return setException(exception, "%s: result: %s", cb.name, err)
+	}
+
+	*result = val
+
+	return true
+}
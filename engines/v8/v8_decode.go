@@ -0,0 +1,654 @@
+package v8
+
+// #include <v8capi.h>
+import "C"
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+// decoder converts JavaScript values to Go values of arbitrary types, it's
+// used by ToObject and to pass arguments to registered functions
+type decoder struct {
+	ignoreUnknownKeys bool
+	// depth is the nesting of the value being decoded
+	depth int
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	bigIntType = reflect.TypeOf(big.Int{})
+)
+
+// maxDepth limits nesting of converted values. A JavaScript object may refer
+// to itself and recursion without a limit would overflow the stack, which
+// can't be recovered
+const maxDepth = 1000
+
+func errTooDeep(path string) error {
+	return fail(path, fmt.Errorf("Value is nested deeper than %d levels, it may be cyclic", maxDepth))
+}
+
+func newDecoder(opts []engines.DecodeOption) *decoder {
+	var options engines.DecodeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &decoder{
+		ignoreUnknownKeys: options.IgnoreUnknownKeys,
+	}
+}
+
+// fail adds the path of the value that can't be decoded to err
+func fail(path string, err error) error {
+	switch {
+	case len(path) == 0:
+		return err
+	case strings.HasPrefix(path, "["):
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	return fmt.Errorf("At %s: %s", path, err)
+}
+
+func typeName(t reflect.Type) string {
+	if len(t.Name()) > 0 {
+		return t.Name()
+	}
+	return t.String()
+}
+
+func isNullish(data C.struct_v8_value) bool {
+	return bool(C.v8_is_null(data)) || bool(C.v8_is_undefined(data))
+}
+
+func (d *decoder) decode(path string, data C.struct_v8_value, value reflect.Value) error {
+	if d.depth > maxDepth {
+		return errTooDeep(path)
+	}
+
+	d.depth++
+	defer func() { d.depth-- }()
+
+	switch value.Type() {
+	case valueType:
+		// engines.Value is a copy owned by the receiver
+		value.Set(reflect.ValueOf(Value{data: C.v8_copy_value(data)}))
+		return nil
+	case timeType:
+		val, err := toTime(data)
+		if err != nil {
+			return fail(path, err)
+		}
+		value.Set(reflect.ValueOf(val))
+		return nil
+	case bigIntType:
+		val, err := toBigInt(data)
+		if err != nil {
+			return fail(path, err)
+		}
+		value.Set(reflect.ValueOf(val).Elem())
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		val, err := toBool(data)
+		if err != nil {
+			return fail(path, err)
+		}
+		value.SetBool(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val, err := toInt(data)
+		if err != nil {
+			return fail(path, err)
+		}
+		if value.OverflowInt(val) {
+			return fail(path, fmt.Errorf("Value %d overflows %s", val, value.Type()))
+		}
+		value.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val, err := toUint(data)
+		if err != nil {
+			return fail(path, err)
+		}
+		if value.OverflowUint(val) {
+			return fail(path, fmt.Errorf("Value %d overflows %s", val, value.Type()))
+		}
+		value.SetUint(val)
+	case reflect.Float32, reflect.Float64:
+		val, err := toFloat(data)
+		if err != nil {
+			return fail(path, err)
+		}
+		if value.OverflowFloat(val) {
+			return fail(path, fmt.Errorf("Value %g overflows %s", val, value.Type()))
+		}
+		value.SetFloat(val)
+	case reflect.String:
+		val, err := toString(data)
+		if err != nil {
+			return fail(path, err)
+		}
+		value.SetString(val)
+	case reflect.Ptr:
+		if isNullish(data) {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return d.decode(path, data, value.Elem())
+	case reflect.Interface:
+		if value.NumMethod() != 0 {
+			return fail(path, fmt.Errorf("Type %q is not supported", value.Type()))
+		}
+		val, err := toInterface(path, data, d.depth)
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			value.Set(reflect.Zero(value.Type()))
+		} else {
+			value.Set(reflect.ValueOf(val))
+		}
+	case reflect.Struct:
+		return d.decodeStruct(path, data, value)
+	case reflect.Map:
+		if isNullish(data) {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+		return d.decodeMap(path, data, value)
+	case reflect.Slice:
+		if isNullish(data) {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+		return d.decodeSlice(path, data, value)
+	case reflect.Array:
+		return d.decodeArray(path, data, value)
+	default:
+		return fail(path, fmt.Errorf("Type %q is not supported", value.Type()))
+	}
+
+	return nil
+}
+
+func isPlainObject(data C.struct_v8_value) bool {
+	return bool(C.v8_is_object(data)) &&
+		!bool(C.v8_is_array(data)) && !bool(C.v8_is_map(data)) && !bool(C.v8_is_set(data))
+}
+
+// forEachPair calls fn for every pair of obj until it returns an error
+func forEachPair(obj C.struct_v8_object, fn func(pair *C.struct_v8_pair_value) error) error {
+	size := int(obj.size)
+	ptr := unsafe.Pointer(obj.data)
+	elemSize := unsafe.Sizeof(*obj.data)
+
+	for i := 0; i < size; i++ {
+		err := fn((*C.struct_v8_pair_value)(ptr))
+		if err != nil {
+			return err
+		}
+		ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+	}
+
+	return nil
+}
+
+func (d *decoder) decodeStruct(path string, data C.struct_v8_value, value reflect.Value) error {
+	if len(path) == 0 {
+		path = typeName(value.Type())
+	}
+
+	if !isPlainObject(data) {
+		return fmt.Errorf("Can't convert %q to %q", typeToString(data), path)
+	}
+
+	fields := structFields(value.Type())
+
+	obj := C.v8_to_object(data)
+
+	size := int(obj.size)
+	ptr := unsafe.Pointer(obj.data)
+	elemSize := unsafe.Sizeof(*obj.data)
+
+	for i := 0; i < size; i++ {
+		pair := (*C.struct_v8_pair_value)(ptr)
+		ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+
+		key, err := toString(pair.first)
+		if err != nil {
+			return fail(path, err)
+		}
+
+		info, ok := fields[key]
+		if !ok {
+			if d.ignoreUnknownKeys {
+				continue
+			}
+			return fmt.Errorf("Type %q has no field %q", path, key)
+		}
+
+		if info.omitEmpty && isNullish(pair.second) {
+			continue
+		}
+
+		err = d.decode(path+"."+key, pair.second, fieldByIndex(value, info.index))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *decoder) decodeMap(path string, data C.struct_v8_value, value reflect.Value) error {
+	if len(path) == 0 {
+		path = typeName(value.Type())
+	}
+
+	if bool(C.v8_is_map(data)) {
+		return d.decodeJSMap(path, data, value)
+	}
+
+	if !isPlainObject(data) {
+		return fail(path, fmt.Errorf("Can't convert %s to %s", typeToString(data), value.Type()))
+	}
+
+	mapType := value.Type()
+
+	if value.IsNil() {
+		value.Set(reflect.MakeMap(mapType))
+	}
+
+	obj := C.v8_to_object(data)
+
+	size := int(obj.size)
+	ptr := unsafe.Pointer(obj.data)
+	elemSize := unsafe.Sizeof(*obj.data)
+
+	for i := 0; i < size; i++ {
+		pair := (*C.struct_v8_pair_value)(ptr)
+		ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+
+		key, err := toString(pair.first)
+		if err != nil {
+			return fail(path, err)
+		}
+
+		keyValue, err := parseMapKey(key, mapType.Key())
+		if err != nil {
+			return fail(path, err)
+		}
+
+		elem := reflect.New(mapType.Elem()).Elem()
+
+		err = d.decode(path+"."+key, pair.second, elem)
+		if err != nil {
+			return err
+		}
+
+		value.SetMapIndex(keyValue, elem)
+	}
+
+	return nil
+}
+
+// decodeJSMap decodes a Map, its keys are decoded like values, so they
+// may be of any type that matches the key type of the Go map
+func (d *decoder) decodeJSMap(path string, data C.struct_v8_value, value reflect.Value) error {
+	mapType := value.Type()
+
+	if value.IsNil() {
+		value.Set(reflect.MakeMap(mapType))
+	}
+
+	i := 0
+
+	return forEachPair(C.v8_map_to_pairs(data), func(pair *C.struct_v8_pair_value) error {
+		entryPath := fmt.Sprintf("%s{%d}", path, i)
+		i++
+
+		key := reflect.New(mapType.Key()).Elem()
+
+		err := d.decode(entryPath+".key", pair.first, key)
+		if err != nil {
+			return err
+		}
+
+		elem := reflect.New(mapType.Elem()).Elem()
+
+		err = d.decode(entryPath+".value", pair.second, elem)
+		if err != nil {
+			return err
+		}
+
+		value.SetMapIndex(key, elem)
+
+		return nil
+	})
+}
+
+// parseMapKey converts a property name to a key of a Go map, numeric keys are
+// parsed with the overflow check
+func parseMapKey(key string, keyType reflect.Type) (reflect.Value, error) {
+	res := reflect.New(keyType).Elem()
+
+	switch keyType.Kind() {
+	case reflect.String:
+		res.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, keyType.Bits())
+		if err != nil {
+			return res, fmt.Errorf("Can't convert key %q to %s", key, keyType)
+		}
+		res.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(key, 10, keyType.Bits())
+		if err != nil {
+			return res, fmt.Errorf("Can't convert key %q to %s", key, keyType)
+		}
+		res.SetUint(u)
+	default:
+		return res, fmt.Errorf("Map key type %q is not supported", keyType)
+	}
+
+	return res, nil
+}
+
+func (d *decoder) decodeSlice(path string, data C.struct_v8_value, value reflect.Value) error {
+	var arr C.struct_v8_array
+
+	switch {
+	case bool(C.v8_is_array(data)):
+		arr = C.v8_to_array(data)
+	case bool(C.v8_is_set(data)):
+		arr = C.v8_set_to_array(data)
+	default:
+		return fail(path, fmt.Errorf("Can't convert %s to %s", typeToString(data), value.Type()))
+	}
+
+	size := int(arr.size)
+	ptr := unsafe.Pointer(arr.data)
+	elemSize := unsafe.Sizeof(*arr.data)
+
+	res := reflect.MakeSlice(value.Type(), size, size)
+
+	for i := 0; i < size; i++ {
+		err := d.decode(fmt.Sprintf("%s[%d]", path, i), *(*C.struct_v8_value)(ptr), res.Index(i))
+		if err != nil {
+			return err
+		}
+		ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+	}
+
+	value.Set(res)
+
+	return nil
+}
+
+func (d *decoder) decodeArray(path string, data C.struct_v8_value, value reflect.Value) error {
+	if !bool(C.v8_is_array(data)) {
+		return fail(path, fmt.Errorf("Can't convert %s to %s", typeToString(data), value.Type()))
+	}
+
+	arr := C.v8_to_array(data)
+
+	size := int(arr.size)
+	if size > value.Len() {
+		return fail(path, fmt.Errorf("Array of %d elements doesn't fit %s", size, value.Type()))
+	}
+
+	ptr := unsafe.Pointer(arr.data)
+	elemSize := unsafe.Sizeof(*arr.data)
+
+	res := reflect.New(value.Type()).Elem()
+
+	for i := 0; i < size; i++ {
+		err := d.decode(fmt.Sprintf("%s[%d]", path, i), *(*C.struct_v8_value)(ptr), res.Index(i))
+		if err != nil {
+			return err
+		}
+		ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+	}
+
+	value.Set(res)
+
+	return nil
+}
+
+// skipped tells if a nested value has no Go counterpart. Like JSON.stringify
+// does, such properties and entries are omitted and elements become nil
+func skipped(data C.struct_v8_value) bool {
+	switch C.v8_get_value_type(data) {
+	case C.v8_function, C.v8_symbol:
+		return true
+	}
+	return false
+}
+
+// toInterface converts a value to nil, bool, int64, float64, string,
+// []interface{}, map[string]interface{}, time.Time or *big.Int. Maps are
+// converted to map[interface{}]interface{} and sets to []interface{}
+func toInterface(path string, data C.struct_v8_value, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errTooDeep(path)
+	}
+
+	switch C.v8_get_value_type(data) {
+	case C.v8_undefined, C.v8_null:
+		return nil, nil
+	case C.v8_boolean:
+		return bool(C.v8_to_bool(data)), nil
+	case C.v8_number:
+		if bool(C.v8_is_integer(data)) {
+			return int64(C.v8_to_int64(data)), nil
+		}
+		return float64(C.v8_to_double(data)), nil
+	case C.v8_string:
+		return toString(data)
+	case C.v8_date:
+		val, err := toTime(data)
+		if err != nil {
+			return nil, fail(path, err)
+		}
+		return val, nil
+	case C.v8_big_int:
+		val, err := toBigInt(data)
+		if err != nil {
+			return nil, fail(path, err)
+		}
+		return val, nil
+	case C.v8_map:
+		res := make(map[interface{}]interface{})
+
+		i := 0
+
+		err := forEachPair(C.v8_map_to_pairs(data), func(pair *C.struct_v8_pair_value) error {
+			entryPath := fmt.Sprintf("%s{%d}", path, i)
+			i++
+
+			if skipped(pair.first) || skipped(pair.second) {
+				return nil
+			}
+
+			key, err := toInterface(entryPath+".key", pair.first, depth+1)
+			if err != nil {
+				return err
+			}
+
+			if key != nil && !reflect.TypeOf(key).Comparable() {
+				return fail(entryPath, fmt.Errorf("Key of type %T can't be a key of a Go map", key))
+			}
+
+			val, err := toInterface(entryPath+".value", pair.second, depth+1)
+			if err != nil {
+				return err
+			}
+
+			res[key] = val
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	case C.v8_array, C.v8_set:
+		var arr C.struct_v8_array
+		if C.v8_get_value_type(data) == C.v8_set {
+			arr = C.v8_set_to_array(data)
+		} else {
+			arr = C.v8_to_array(data)
+		}
+
+		size := int(arr.size)
+		ptr := unsafe.Pointer(arr.data)
+		elemSize := unsafe.Sizeof(*arr.data)
+
+		res := make([]interface{}, size)
+
+		for i := 0; i < size; i++ {
+			elem := *(*C.struct_v8_value)(ptr)
+			ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+
+			if skipped(elem) {
+				continue
+			}
+
+			val, err := toInterface(fmt.Sprintf("%s[%d]", path, i), elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = val
+		}
+
+		return res, nil
+	case C.v8_object:
+		obj := C.v8_to_object(data)
+
+		size := int(obj.size)
+		ptr := unsafe.Pointer(obj.data)
+		elemSize := unsafe.Sizeof(*obj.data)
+
+		res := make(map[string]interface{}, size)
+
+		for i := 0; i < size; i++ {
+			pair := (*C.struct_v8_pair_value)(ptr)
+			ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+
+			if skipped(pair.first) || skipped(pair.second) {
+				continue
+			}
+
+			key, err := toString(pair.first)
+			if err != nil {
+				return nil, fail(path, err)
+			}
+
+			val, err := toInterface(path+"."+key, pair.second, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			res[key] = val
+		}
+
+		return res, nil
+	}
+
+	return nil, fail(path, fmt.Errorf("Can't convert %s to interface{}", typeToString(data)))
+}
+
+type fieldInfo struct {
+	index     []int
+	omitEmpty bool
+}
+
+// fields maps a struct type to its fields by the names used in JavaScript
+var fields sync.Map
+
+func structFields(structType reflect.Type) map[string]fieldInfo {
+	if res, ok := fields.Load(structType); ok {
+		return res.(map[string]fieldInfo)
+	}
+
+	res := make(map[string]fieldInfo)
+	collectFields(structType, nil, res)
+
+	fields.Store(structType, res)
+
+	return res
+}
+
+// collectFields adds fields of the struct, fields of embedded structs are
+// added after the own ones, so the outer fields win
+func collectFields(structType reflect.Type, index []int, res map[string]fieldInfo) {
+	type embedded struct {
+		structType reflect.Type
+		index      []int
+	}
+
+	var embeddedStructs []embedded
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		name, omitEmpty, skip := parseTag(field)
+		if skip {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && fieldType.Kind() == reflect.Struct && name == field.Name {
+			embeddedStructs = append(embeddedStructs, embedded{fieldType, fieldIndex})
+			continue
+		}
+
+		if _, ok := res[name]; !ok {
+			res[name] = fieldInfo{index: fieldIndex, omitEmpty: omitEmpty}
+		}
+	}
+
+	for _, e := range embeddedStructs {
+		collectFields(e.structType, e.index, res)
+	}
+}
+
+// fieldByIndex returns a settable field, unexported fields included.
+// Nil pointers to embedded structs are allocated on the way
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+
+		value = value.Field(x)
+		if !value.CanSet() {
+			value = reflect.NewAt(value.Type(), unsafe.Pointer(value.UnsafeAddr())).Elem()
+		}
+	}
+
+	return value
+}
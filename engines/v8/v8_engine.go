@@ -11,68 +11,218 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"unsafe"
 
 	"github.com/mtrempoltsev/gojs/engines"
 )
 
-func makeError(err C.struct_v8_error) string {
+var (
+	// only names of error classes are taken, so a thrown "timeout: 5s" or
+	// an exception of a Go callback keeps its message as is
+	exceptionName = regexp.MustCompile(`^([A-Za-z_$][\w$]*(?:Error|Exception)): (.*)$`)
+	stackFrame    = regexp.MustCompile(`^\s*at (?:(.+?) \()?(.+):(\d+):(\d+)\)?$`)
+)
+
+func parseStackTrace(stackTrace string) []engines.StackFrame {
+	var frames []engines.StackFrame
+
+	for _, line := range strings.Split(stackTrace, "\n") {
+		match := stackFrame.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		lineNumber, _ := strconv.Atoi(match[3])
+		column, _ := strconv.Atoi(match[4])
+
+		frames = append(frames, engines.StackFrame{
+			Function: match[1],
+			File:     match[2],
+			Line:     lineNumber,
+			Column:   column,
+		})
+	}
+
+	return frames
+}
+
+// executionError converts an error of a script execution, the error of
+// a script terminated on reaching the heap limit is replaced by ErrHeapLimit
+func executionError(isolate *C.struct_v8_isolate, err *C.struct_v8_error) error {
+	defer C.v8_delete_error(err)
+
+	if bool(C.v8_heap_limit_reached(isolate)) {
+		return engines.ErrHeapLimit
+	}
+
+	return makeError(*err)
+}
+
+func makeError(err C.struct_v8_error) error {
+	res := &engines.JSError{
+		Message: strings.TrimPrefix(C.GoString(err.message), "Uncaught "),
+	}
+
+	if match := exceptionName.FindStringSubmatch(res.Message); match != nil {
+		res.Name = match[1]
+		res.Message = match[2]
+	}
+
 	if err.location == nil {
-		return C.GoString(err.message)
+		return res
 	}
 
-	buf := strings.Builder{}
+	res.File = C.GoString(err.location)
+	res.Line = int(err.line_number)
 
-	fmt.Fprintf(&buf,
-		"%s:%d: %s\n%s",
-		C.GoString(err.location),
-		err.line_number,
-		C.GoString(err.message),
-		C.GoString(err.wavy_underline))
+	// the wavy underline is the source line followed by a line with '^'
+	// marks below the place where the error has happened
+	underline := C.GoString(err.wavy_underline)
+	if pos := strings.LastIndexByte(underline, '\n'); pos >= 0 {
+		res.SourceLine = underline[:pos]
+		underline = underline[pos+1:]
+	}
+
+	if start := strings.IndexByte(underline, '^'); start >= 0 {
+		res.Column = start + 1
+		res.EndColumn = strings.LastIndexByte(underline, '^') + 2
+	} else if len(res.SourceLine) == 0 {
+		res.SourceLine = underline
+	}
 
 	if err.stack_trace != nil {
-		fmt.Fprintf(&buf,
-			"\nstack trace:\n%s",
-			C.GoString(err.stack_trace))
+		res.Stack = parseStackTrace(C.GoString(err.stack_trace))
 	}
 
-	return buf.String()
+	return res
 }
 
 type Function struct {
-	ptr *C.struct_v8_callable
+	ptr     *C.struct_v8_callable
+	isolate *C.struct_v8_isolate
 }
 
 type Script struct {
-	ptr *C.struct_v8_script
+	ptr     *C.struct_v8_script
+	isolate *C.struct_v8_isolate
+}
+
+type Context struct {
+	ptr     *C.struct_v8_context
+	isolate *C.struct_v8_isolate
 }
 
 type Runner struct {
-	ptr *C.struct_v8_isolate
+	ptr     *C.struct_v8_isolate
+	handles []C.uintptr_t
+	// snapshot must outlive the isolate created from it
+	snapshot unsafe.Pointer
+	mutex    sync.Mutex
 }
 
 type Engine struct {
 	ptr *C.struct_v8_instance
 }
 
+// V8 is initialized once per process and can't be initialized again after it
+// has been disposed, so all engines share the instance created by the first
+// call of New and it lives until the process exits
+var instance struct {
+	ptr   *C.struct_v8_instance
+	mutex sync.Mutex
+}
+
+// New returns an engine backed by the process-wide V8 instance. runnersNum
+// sizes the platform, only the value passed to the first call is used
 func New(runnersNum int) (engines.Engine, error) {
-	path := C.CString(os.Args[0])
-	defer C.free(unsafe.Pointer(path))
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+
+	if instance.ptr == nil {
+		path := C.CString(os.Args[0])
+		defer C.free(unsafe.Pointer(path))
+
+		instance.ptr = C.v8_new_instance(C.uint(runnersNum), path)
+		if instance.ptr == nil {
+			return nil, errors.New("gojs.Executor: can't initialize V8")
+		}
+	}
 
 	return &Engine{
-		ptr: C.v8_new_instance(C.uint(runnersNum), path),
+		ptr: instance.ptr,
 	}, nil
 }
 
-func (*Engine) NewRunner() (engines.Runner, error) {
+func (*Engine) NewRunner(options engines.RunnerOptions) (engines.Runner, error) {
+	params := C.struct_v8_isolate_params{
+		max_old_generation_size:   C.size_t(options.MaxOldGenerationSize),
+		max_young_generation_size: C.size_t(options.MaxYoungGenerationSize),
+	}
+
+	var snapshot unsafe.Pointer
+	if len(options.Snapshot) > 0 {
+		snapshot = C.CBytes(options.Snapshot)
+		params.snapshot_data = (*C.char)(snapshot)
+		params.snapshot_size = C.int(len(options.Snapshot))
+	}
+
+	isolate := C.v8_new_isolate_with_params(&params)
+	if isolate == nil {
+		C.free(snapshot)
+		return nil, errors.New("gojs.Executor: can't create an isolate, the snapshot may be corrupted")
+	}
+
 	return &Runner{
-		ptr: C.v8_new_isolate(),
+		ptr:      isolate,
+		snapshot: snapshot,
 	}, nil
 }
 
+func (*Engine) BuildSnapshot(scripts []engines.SnapshotScript) ([]byte, error) {
+	n := len(scripts)
+
+	codes := make([]*C.char, n)
+	names := make([]*C.char, n)
+	for i, script := range scripts {
+		codes[i] = C.CString(script.Code)
+		names[i] = C.CString(script.Name)
+	}
+
+	defer func() {
+		for i := 0; i < n; i++ {
+			C.free(unsafe.Pointer(codes[i]))
+			C.free(unsafe.Pointer(names[i]))
+		}
+	}()
+
+	var codesPtr, namesPtr **C.char
+	if n > 0 {
+		codesPtr = &codes[0]
+		namesPtr = &names[0]
+	}
+
+	var data C.struct_v8_snapshot_data
+	defer C.v8_delete_snapshot_data(&data)
+
+	var err C.struct_v8_error
+	defer C.v8_delete_error(&err)
+
+	if !C.v8_create_snapshot(codesPtr, namesPtr, C.int(n), &data, &err) {
+		return nil, makeError(err)
+	}
+
+	return C.GoBytes(unsafe.Pointer(data.data), data.size), nil
+}
+
+// Dispose keeps the shared V8 instance, other engines and BuildSnapshot may
+// still use it
 func (engine *Engine) Dispose() {
-	C.v8_delete_instance(engine.ptr)
+	engine.ptr = nil
 }
 
 func (runner *Runner) Compile(name, code string) (engines.Script, error) {
@@ -88,14 +238,167 @@ func (runner *Runner) Compile(name, code string) (engines.Script, error) {
 	script := C.v8_compile_script(runner.ptr, codePtr, namePtr, &err)
 
 	if script == nil {
-		return nil, errors.New(makeError(err))
+		return nil, makeError(err)
 	}
 
-	return &Script{script}, nil
+	return &Script{script, runner.ptr}, nil
+}
+
+func (runner *Runner) CompileWithCache(name, code string, cache []byte) (engines.Script, bool, error) {
+	if len(cache) == 0 {
+		script, err := runner.Compile(name, code)
+		return script, true, err
+	}
+
+	codePtr := C.CString(code)
+	defer C.free(unsafe.Pointer(codePtr))
+
+	namePtr := C.CString(name)
+	defer C.free(unsafe.Pointer(namePtr))
+
+	cachePtr := C.CBytes(cache)
+	defer C.free(cachePtr)
+
+	var rejected C.bool
+
+	var err C.struct_v8_error
+	defer C.v8_delete_error(&err)
+
+	script := C.v8_compile_script_with_cache(
+		runner.ptr, codePtr, namePtr, (*C.char)(cachePtr), C.int(len(cache)), &rejected, &err)
+
+	if script == nil {
+		return nil, bool(rejected), makeError(err)
+	}
+
+	return &Script{script, runner.ptr}, bool(rejected), nil
+}
+
+func (runner *Runner) NewContext() (engines.Context, error) {
+	context := C.v8_new_context(runner.ptr)
+	if context == nil {
+		return nil, errors.New("gojs.Executor: can't create a context")
+	}
+
+	return &Context{context, runner.ptr}, nil
+}
+
+func (context *Context) Compile(name, code string) (engines.Script, error) {
+	codePtr := C.CString(code)
+	defer C.free(unsafe.Pointer(codePtr))
+
+	namePtr := C.CString(name)
+	defer C.free(unsafe.Pointer(namePtr))
+
+	var err C.struct_v8_error
+	defer C.v8_delete_error(&err)
+
+	script := C.v8_compile_script_in_context(context.ptr, codePtr, namePtr, &err)
+
+	if script == nil {
+		return nil, makeError(err)
+	}
+
+	return &Script{script, context.isolate}, nil
+}
+
+func (context *Context) Dispose() {
+	C.v8_delete_context(context.ptr)
+}
+
+// CompileFunction compiles code as the body of a function with the given
+// parameters in the context of the currently running script, so it can be
+// used only from functions registered by RegisterFunc
+func (runner *Runner) CompileFunction(name, code string, params []string) (engines.Value, error) {
+	codePtr := C.CString(code)
+	defer C.free(unsafe.Pointer(codePtr))
+
+	namePtr := C.CString(name)
+	defer C.free(unsafe.Pointer(namePtr))
+
+	paramPtrs := make([]*C.char, len(params))
+	for i, param := range params {
+		paramPtrs[i] = C.CString(param)
+		defer C.free(unsafe.Pointer(paramPtrs[i]))
+	}
+
+	var paramsPtr **C.char
+	if len(paramPtrs) > 0 {
+		paramsPtr = &paramPtrs[0]
+	}
+
+	var res C.struct_v8_value
+
+	var err C.struct_v8_error
+	defer C.v8_delete_error(&err)
+
+	if !C.v8_compile_function(runner.ptr, codePtr, namePtr, paramsPtr, C.int(len(params)), &res, &err) {
+		return nil, makeError(err)
+	}
+
+	return Value{data: res}, nil
+}
+
+func (runner *Runner) NewValue(val interface{}) (engines.Value, error) {
+	data, err := newValue(runner.ptr, reflect.ValueOf(val), 0)
+	if err != nil {
+		return nil, err
+	}
+	return Value{data: data}, nil
+}
+
+func (runner *Runner) RunMicrotasks() {
+	C.v8_perform_microtask_checkpoint(runner.ptr)
+}
+
+func (runner *Runner) EnqueueMicrotask(fn engines.Value) error {
+	val, ok := fn.(Value)
+	if !ok || !bool(C.v8_is_function(val.data)) {
+		return fmt.Errorf("Can't enqueue %T as a microtask", fn)
+	}
+
+	C.v8_enqueue_microtask(runner.ptr, val.data)
+
+	return nil
+}
+
+// HeapLimitReached reports that the runner has reached its heap limit, the
+// script that was running at that moment has been terminated
+func (runner *Runner) HeapLimitReached() bool {
+	return bool(C.v8_heap_limit_reached(runner.ptr))
+}
+
+func (runner *Runner) IsDead() bool {
+	return bool(C.v8_is_dead(runner.ptr))
+}
+
+func (runner *Runner) HeapStatistics() engines.HeapStatistics {
+	var stats C.struct_v8_heap_statistics
+
+	C.v8_get_heap_statistics(runner.ptr, &stats)
+
+	return engines.HeapStatistics{
+		TotalHeapSize:  uint64(stats.total_heap_size),
+		UsedHeapSize:   uint64(stats.used_heap_size),
+		HeapSizeLimit:  uint64(stats.heap_size_limit),
+		ExternalMemory: uint64(stats.external_memory),
+	}
+}
+
+func (runner *Runner) Terminate() {
+	C.v8_terminate_execution(runner.ptr)
+}
+
+func (runner *Runner) CancelTerminate() {
+	C.v8_cancel_terminate_execution(runner.ptr)
 }
 
 func (runner *Runner) Dispose() {
 	C.v8_delete_isolate(runner.ptr)
+	C.free(runner.snapshot)
+	for _, id := range runner.handles {
+		removeHandle(id)
+	}
 }
 
 func (script *Script) Run() (engines.Value, error) {
@@ -107,18 +410,49 @@ func (script *Script) Run() (engines.Value, error) {
 		return Value{data: res}, nil
 	}
 
-	str := makeError(err)
-	C.v8_delete_error(&err)
-	return nil, errors.New(str)
+	return nil, executionError(script.isolate, &err)
+}
+
+func (script *Script) CodeCache() ([]byte, error) {
+	var cache C.struct_v8_code_cache
+	defer C.v8_delete_code_cache(&cache)
+
+	if !C.v8_create_code_cache(script.ptr, &cache) {
+		return nil, errors.New("gojs.Executor: can't create the code cache")
+	}
+
+	return C.GoBytes(unsafe.Pointer(cache.data), cache.size), nil
+}
+
+func (script *Script) Reset() error {
+	var err C.struct_v8_error
+	defer C.v8_delete_error(&err)
+
+	if !C.v8_reset_script_context(script.ptr, &err) {
+		return makeError(err)
+	}
+
+	return nil
 }
 
 func (script *Script) Terminate() {
-	// TODO: implement this
+	C.v8_terminate_execution(script.isolate)
 }
 
 func (script *Script) GetFunction(funcName string) (engines.Function, error) {
-	// TODO: implement this
-	return nil, nil
+	namePtr := C.CString(funcName)
+	defer C.free(unsafe.Pointer(namePtr))
+
+	var err C.struct_v8_error
+	defer C.v8_delete_error(&err)
+
+	function := C.v8_get_function(script.ptr, namePtr, &err)
+
+	if function == nil {
+		return nil, makeError(err)
+	}
+
+	return &Function{function, script.isolate}, nil
 }
 
 func (script *Script) Dispose() {
@@ -126,12 +460,35 @@ func (script *Script) Dispose() {
 }
 
 func (function *Function) Call(args ...engines.Value) (engines.Value, error) {
-	// TODO: implement this
-	return nil, nil
+	var argsPtr *C.struct_v8_value
+
+	values := make([]C.struct_v8_value, len(args))
+
+	for i, arg := range args {
+		val, ok := arg.(Value)
+		if !ok {
+			return nil, fmt.Errorf("Argument %d was not created by v8 engine", i)
+		}
+		values[i] = val.data
+	}
+
+	if len(values) > 0 {
+		argsPtr = &values[0]
+	}
+
+	var res C.struct_v8_value
+
+	var err C.struct_v8_error
+
+	if C.v8_call_function(function.ptr, argsPtr, C.int(len(values)), &res, &err) {
+		return Value{data: res}, nil
+	}
+
+	return nil, executionError(function.isolate, &err)
 }
 
 func (function *Function) Terminate() {
-	// TODO: implement this
+	C.v8_terminate_execution(function.isolate)
 }
 
 func (function *Function) Dispose() {
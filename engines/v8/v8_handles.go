@@ -0,0 +1,39 @@
+package v8
+
+// #include <v8capi.h>
+import "C"
+
+import "sync"
+
+// Go pointers can't be passed to C, so C gets an identifier that is
+// resolved through this registry when C calls back into Go
+var handles = struct {
+	sync.RWMutex
+	items  map[C.uintptr_t]interface{}
+	nextID C.uintptr_t
+}{
+	items: make(map[C.uintptr_t]interface{}),
+}
+
+func addHandle(item interface{}) C.uintptr_t {
+	handles.Lock()
+	defer handles.Unlock()
+
+	handles.nextID++
+	handles.items[handles.nextID] = item
+
+	return handles.nextID
+}
+
+func removeHandle(id C.uintptr_t) {
+	handles.Lock()
+	delete(handles.items, id)
+	handles.Unlock()
+}
+
+func getHandle(id C.uintptr_t) interface{} {
+	handles.RLock()
+	defer handles.RUnlock()
+
+	return handles.items[id]
+}
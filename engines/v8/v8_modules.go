@@ -0,0 +1,220 @@
+package v8
+
+// #include <stdlib.h>
+// #include <v8capi.h>
+//
+// extern struct v8_module* goResolveModule(uintptr_t data, char* specifier, struct v8_module* referrer, char** exception);
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+type Module struct {
+	ptr       *C.struct_v8_module
+	isolate   *C.struct_v8_isolate
+	graph     *moduleGraph
+	evaluated bool
+}
+
+// moduleGraph keeps the root module and all modules it imports. Imports are
+// shared only inside the graph and disposed with the root, so a replaced
+// module never keeps running stale dependencies
+type moduleGraph struct {
+	modules   map[string]*C.struct_v8_module
+	moduleIDs map[*C.struct_v8_module]string
+}
+
+func newModuleGraph() *moduleGraph {
+	return &moduleGraph{
+		modules:   make(map[string]*C.struct_v8_module),
+		moduleIDs: make(map[*C.struct_v8_module]string),
+	}
+}
+
+func (graph *moduleGraph) add(id string, module *C.struct_v8_module) {
+	graph.modules[id] = module
+	graph.moduleIDs[module] = id
+}
+
+func (graph *moduleGraph) dispose() {
+	for module := range graph.moduleIDs {
+		C.v8_delete_module(module)
+	}
+}
+
+type moduleResolver struct {
+	isolate *C.struct_v8_isolate
+	loader  engines.ModuleLoader
+	graph   *moduleGraph
+}
+
+func compileModule(isolate *C.struct_v8_isolate, id, code string) (*C.struct_v8_module, error) {
+	codePtr := C.CString(code)
+	defer C.free(unsafe.Pointer(codePtr))
+
+	idPtr := C.CString(id)
+	defer C.free(unsafe.Pointer(idPtr))
+
+	var err C.struct_v8_error
+	defer C.v8_delete_error(&err)
+
+	module := C.v8_compile_module(isolate, codePtr, idPtr, &err)
+
+	if module == nil {
+		return nil, makeError(err)
+	}
+
+	return module, nil
+}
+
+func (resolver *moduleResolver) resolve(specifier string, referrer *C.struct_v8_module) (*C.struct_v8_module, error) {
+	if resolver.loader == nil {
+		return nil, fmt.Errorf("Can't import %q, module loader is not set", specifier)
+	}
+
+	referrerID, ok := resolver.graph.moduleIDs[referrer]
+	if !ok {
+		return nil, fmt.Errorf("Can't import %q from unknown module", specifier)
+	}
+
+	id, source, err := resolver.loader.Resolve(specifier, referrerID)
+	if err != nil {
+		return nil, err
+	}
+
+	module := resolver.graph.modules[id]
+	if module != nil {
+		return module, nil
+	}
+
+	module, err = compileModule(resolver.isolate, id, source)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver.graph.add(id, module)
+
+	return module, nil
+}
+
+//export goResolveModule
+func goResolveModule(data C.uintptr_t, specifier *C.char, referrer *C.struct_v8_module, exception **C.char) *C.struct_v8_module {
+	resolver, _ := getHandle(data).(*moduleResolver)
+	if resolver == nil {
+		*exception = C.CString("Module resolver is not registered")
+		return nil
+	}
+
+	module, err := resolver.resolve(C.GoString(specifier), referrer)
+	if err != nil {
+		*exception = C.CString(err.Error())
+		return nil
+	}
+
+	return module
+}
+
+// CompileModule compiles an ES module. Imported modules are requested from
+// loader and cached by the module, so every module it imports is evaluated
+// once
+func (runner *Runner) CompileModule(id, code string, loader engines.ModuleLoader) (engines.Script, error) {
+	module, err := compileModule(runner.ptr, id, code)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := newModuleGraph()
+	graph.add(id, module)
+
+	resolver := &moduleResolver{
+		isolate: runner.ptr,
+		loader:  loader,
+		graph:   graph,
+	}
+
+	handle := addHandle(resolver)
+	defer removeHandle(handle)
+
+	var cErr C.struct_v8_error
+	defer C.v8_delete_error(&cErr)
+
+	if !C.v8_instantiate_module(module, C.v8_resolve_callback(C.goResolveModule), handle, &cErr) {
+		graph.dispose()
+		return nil, makeError(cErr)
+	}
+
+	return &Module{
+		ptr:     module,
+		isolate: runner.ptr,
+		graph:   graph,
+	}, nil
+}
+
+// Run evaluates the module the first time it is called and returns its
+// namespace object
+func (module *Module) Run() (engines.Value, error) {
+	if !module.evaluated {
+		var res C.struct_v8_value
+
+		var err C.struct_v8_error
+
+		if !C.v8_evaluate_module(module.ptr, &res, &err) {
+			return nil, executionError(module.isolate, &err)
+		}
+
+		C.v8_delete_value(&res)
+
+		module.evaluated = true
+	}
+
+	return Value{data: C.v8_get_module_namespace(module.ptr)}, nil
+}
+
+func (module *Module) Terminate() {
+	C.v8_terminate_execution(module.isolate)
+}
+
+// GetFunction returns a function exported by the module
+func (module *Module) GetFunction(funcName string) (engines.Function, error) {
+	namespace, err := module.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	defer namespace.Dispose()
+
+	obj := C.v8_to_object(namespace.(Value).data)
+
+	ptr := unsafe.Pointer(obj.data)
+	elemSize := unsafe.Sizeof(*obj.data)
+
+	for i := 0; i < int(obj.size); i++ {
+		pair := (*C.struct_v8_pair_value)(ptr)
+
+		name, err := toString(pair.first)
+		if err == nil && name == funcName {
+			return Value{data: pair.second}.ToFunction()
+		}
+
+		ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+	}
+
+	return nil, fmt.Errorf("Module does not export %q", funcName)
+}
+
+func (module *Module) CodeCache() ([]byte, error) {
+	return nil, errors.New("gojs.Executor: the code cache isn't supported for modules")
+}
+
+func (module *Module) Reset() error {
+	return errors.New("gojs.Executor: modules can't be moved to a new context")
+}
+
+func (module *Module) Dispose() {
+	module.graph.dispose()
+}
@@ -1,12 +1,23 @@
 package v8
 
+// #include <stdlib.h>
 // #include <v8capi.h>
 import "C"
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 	"unsafe"
+
+	"github.com/mtrempoltsev/gojs/engines"
 )
 
 type Value struct {
@@ -45,10 +56,90 @@ func typeToString(data C.struct_v8_value) string {
 		return "function"
 	case C.v8_date:
 		return "date"
+	case C.v8_promise:
+		return "promise"
 	}
 	return "[unknown type]"
 }
 
+// objects nested deeper are formatted by their type only, it also protects
+// from cyclic references
+const maxFormatDepth = 4
+
+func formatValue(buf *strings.Builder, data C.struct_v8_value, quote bool, depth int) {
+	valueType := C.v8_get_value_type(data)
+
+	if depth > maxFormatDepth && (valueType == C.v8_array || valueType == C.v8_object) {
+		buf.WriteString("[" + typeToString(data) + "]")
+		return
+	}
+
+	switch valueType {
+	case C.v8_boolean:
+		buf.WriteString(strconv.FormatBool(bool(C.v8_to_bool(data))))
+	case C.v8_number:
+		buf.WriteString(strconv.FormatFloat(float64(C.v8_to_double(data)), 'g', -1, 64))
+	case C.v8_string:
+		str, _ := toString(data)
+		if quote {
+			buf.WriteString(strconv.Quote(str))
+		} else {
+			buf.WriteString(str)
+		}
+	case C.v8_array:
+		arr := C.v8_to_array(data)
+
+		ptr := unsafe.Pointer(arr.data)
+		elemSize := unsafe.Sizeof(*arr.data)
+
+		buf.WriteByte('[')
+		for i := 0; i < int(arr.size); i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			formatValue(buf, *(*C.struct_v8_value)(ptr), true, depth+1)
+			ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+		}
+		buf.WriteByte(']')
+	case C.v8_object:
+		obj := C.v8_to_object(data)
+
+		ptr := unsafe.Pointer(obj.data)
+		elemSize := unsafe.Sizeof(*obj.data)
+
+		buf.WriteByte('{')
+		for i := 0; i < int(obj.size); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			pair := (*C.struct_v8_pair_value)(ptr)
+			buf.WriteByte(' ')
+			formatValue(buf, pair.first, false, depth+1)
+			buf.WriteString(": ")
+			formatValue(buf, pair.second, true, depth+1)
+			ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+		}
+		if obj.size > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteByte('}')
+	case C.v8_undefined:
+		fallthrough
+	case C.v8_null:
+		buf.WriteString(typeToString(data))
+	default:
+		buf.WriteString("[" + typeToString(data) + "]")
+	}
+}
+
+// String formats the value in a human readable form similar to the output
+// of console.log
+func (val Value) String() string {
+	buf := strings.Builder{}
+	formatValue(&buf, val.data, false, 0)
+	return buf.String()
+}
+
 func (val Value) IsUndefined() bool {
 	return bool(C.v8_is_undefined(val.data))
 }
@@ -93,6 +184,75 @@ func (val Value) IsMap() bool {
 	return bool(C.v8_is_map(val.data))
 }
 
+func (val Value) IsFunction() bool {
+	return bool(C.v8_is_function(val.data))
+}
+
+func (val Value) IsPromise() bool {
+	return bool(C.v8_is_promise(val.data))
+}
+
+func (val Value) IsBigInt() bool {
+	return bool(C.v8_is_big_int(val.data))
+}
+
+func (val Value) IsDate() bool {
+	return bool(C.v8_is_date(val.data))
+}
+
+func (val Value) IsSymbol() bool {
+	return bool(C.v8_is_symbol(val.data))
+}
+
+func (val Value) ToFunction() (engines.Function, error) {
+	if !bool(C.v8_is_function(val.data)) {
+		return nil, fmt.Errorf("Can't convert %s to function", typeToString(val.data))
+	}
+
+	return &Function{
+		ptr:     C.v8_to_function(val.data),
+		isolate: C.v8_get_isolate(val.data),
+	}, nil
+}
+
+func (val Value) PromiseState() (engines.PromiseState, error) {
+	if !bool(C.v8_is_promise(val.data)) {
+		return engines.PromisePending, fmt.Errorf("Can't get promise state of %s", typeToString(val.data))
+	}
+
+	switch C.v8_get_promise_state(val.data) {
+	case C.v8_fulfilled:
+		return engines.PromiseFulfilled, nil
+	case C.v8_rejected:
+		return engines.PromiseRejected, nil
+	}
+	return engines.PromisePending, nil
+}
+
+func (val Value) PromiseResult() (engines.Value, error) {
+	state, err := val.PromiseState()
+	if err != nil {
+		return nil, err
+	}
+
+	switch state {
+	case engines.PromisePending:
+		return nil, errors.New("Promise is still pending")
+	case engines.PromiseRejected:
+		reason := C.v8_get_promise_result(val.data)
+		defer C.v8_delete_value(&reason)
+
+		var cErr C.struct_v8_error
+		defer C.v8_delete_error(&cErr)
+
+		C.v8_value_to_error(reason, &cErr)
+
+		return nil, makeError(cErr)
+	}
+
+	return Value{data: C.v8_get_promise_result(val.data)}, nil
+}
+
 func toBool(data C.struct_v8_value) (bool, error) {
 	if bool(C.v8_is_boolean(data)) {
 		return bool(C.v8_to_bool(data)), nil
@@ -153,121 +313,116 @@ func (val Value) ToString() (string, error) {
 	return toString(val.data)
 }
 
-func toObject(typeName string, data C.struct_v8_value, value reflect.Value) error {
-	if !bool(C.v8_is_object(data)) {
-		return fmt.Errorf("Can't convert %q to %q", typeToString(data), typeName)
+func toTime(data C.struct_v8_value) (time.Time, error) {
+	if !bool(C.v8_is_date(data)) {
+		return time.Time{}, fmt.Errorf("Can't convert %s to time.Time", typeToString(data))
 	}
 
-	obj := C.v8_to_object(data)
+	ms := float64(C.v8_date_to_time(data))
+	if math.IsNaN(ms) {
+		return time.Time{}, errors.New("Can't convert invalid date to time.Time")
+	}
 
-	size := int(obj.size)
-	ptr := unsafe.Pointer(obj.data)
-	elemSize := unsafe.Sizeof(*obj.data)
+	// time values of dates are whole milliseconds, integer math keeps them
+	// exact where a float would be off by tens of nanoseconds
+	msInt := int64(ms)
 
-	applier := reflect.Indirect(value)
+	return time.Unix(msInt/1000, (msInt%1000)*int64(time.Millisecond)), nil
+}
 
-	for i := 0; i < size; i++ {
-		data := (*C.struct_v8_pair_value)(ptr)
+func (val Value) ToTime() (time.Time, error) {
+	return toTime(val.data)
+}
 
-		fieldName, err := toString(data.first)
-		if err != nil {
-			return fmt.Errorf("At %s.%s: %s", typeName, fieldName, err)
-		}
+func toBigInt(data C.struct_v8_value) (*big.Int, error) {
+	if !bool(C.v8_is_big_int(data)) {
+		return nil, fmt.Errorf("Can't convert %s to *big.Int", typeToString(data))
+	}
 
-		field := applier.FieldByName(fieldName)
-		if !field.IsValid() {
-			return fmt.Errorf("Type %q does not has field %q", typeName, fieldName)
-		}
+	count := C.int(C.v8_big_int_word_count(data))
+	if count == 0 {
+		return new(big.Int), nil
+	}
 
-		fieldPtr := unsafe.Pointer(field.UnsafeAddr())
+	var sign C.int
+	words := make([]C.uint64_t, count)
 
-		switch field.Kind() {
-		case reflect.Bool:
-			val, err := toBool(data.second)
-			if err != nil {
-				return fmt.Errorf("At %s.%s: %s", typeName, fieldName, err)
-			}
-			valPtr := (*bool)(fieldPtr)
-			*valPtr = val
-		case reflect.Int:
-			fallthrough
-		case reflect.Int8:
-			fallthrough
-		case reflect.Int16:
-			fallthrough
-		case reflect.Int32:
-			fallthrough
-		case reflect.Int64:
-			val, err := toInt(data.second)
-			if err != nil {
-				return fmt.Errorf("At %s.%s: %s", typeName, fieldName, err)
-			}
-			valPtr := (*int64)(fieldPtr)
-			*valPtr = val
-		case reflect.Uint:
-			fallthrough
-		case reflect.Uint8:
-			fallthrough
-		case reflect.Uint16:
-			fallthrough
-		case reflect.Uint32:
-			fallthrough
-		case reflect.Uint64:
-			val, err := toUint(data.second)
-			if err != nil {
-				return fmt.Errorf("At %s.%s: %s", typeName, fieldName, err)
-			}
-			valPtr := (*uint64)(fieldPtr)
-			*valPtr = val
-		case reflect.Float32:
-			fallthrough
-		case reflect.Float64:
-			val, err := toFloat(data.second)
-			if err != nil {
-				return fmt.Errorf("At %s.%s: %s", typeName, fieldName, err)
-			}
-			valPtr := (*float64)(fieldPtr)
-			*valPtr = val
-		case reflect.Array:
-			err := toArray(data.second, field.Elem().Kind(), fieldPtr)
-			if err != nil {
-				return fmt.Errorf("At %s.%s: %s", typeName, fieldName, err)
-			}
-		case reflect.Map:
-		case reflect.Slice:
-			err := toArray(data.second, field.Type().Elem().Kind(), fieldPtr)
-			if err != nil {
-				return fmt.Errorf("At %s.%s: %s", typeName, fieldName, err)
-			}
-		case reflect.String:
-			val, err := toString(data.second)
-			if err != nil {
-				return fmt.Errorf("At %s.%s: %s", typeName, fieldName, err)
-			}
-			valPtr := (*string)(fieldPtr)
-			*valPtr = val
-		case reflect.Struct:
-			err := toObject(typeName+"."+fieldName, data.second, reflect.NewAt(field.Type(), fieldPtr))
-			if err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("Field %q of type %q has unsupported type", fieldName, typeName)
+	C.v8_big_int_to_words(data, &sign, &count, &words[0])
+
+	// the words are little-endian, big.Int wants big-endian bytes
+	bytes := make([]byte, 8*int(count))
+	for i := 0; i < int(count); i++ {
+		binary.BigEndian.PutUint64(bytes[len(bytes)-8*(i+1):], uint64(words[i]))
+	}
+
+	res := new(big.Int).SetBytes(bytes)
+	if sign != 0 {
+		res.Neg(res)
+	}
+
+	return res, nil
+}
+
+func (val Value) ToBigInt() (*big.Int, error) {
+	return toBigInt(val.data)
+}
+
+func (val Value) SymbolDescription() (string, error) {
+	if !bool(C.v8_is_symbol(val.data)) {
+		return "", fmt.Errorf("Can't get symbol description of %s", typeToString(val.data))
+	}
+
+	desc := C.v8_get_symbol_description(val.data)
+	defer C.v8_delete_value(&desc)
+
+	if bool(C.v8_is_undefined(desc)) {
+		return "", nil
+	}
+
+	return toString(desc)
+}
+
+func newDate(isolate *C.struct_v8_isolate, t time.Time) C.struct_v8_value {
+	ms := float64(t.Unix())*1000 + float64(t.Nanosecond())/1e6
+	return C.v8_new_date(isolate, C.double(ms))
+}
+
+func newBigInt(isolate *C.struct_v8_isolate, b *big.Int) C.struct_v8_value {
+	bytes := b.Bytes()
+
+	// V8 wants little-endian 64-bit words of the absolute value
+	words := make([]C.uint64_t, (len(bytes)+7)/8)
+	for i := range words {
+		end := len(bytes) - 8*i
+		start := end - 8
+		if start < 0 {
+			start = 0
 		}
 
-		ptr = unsafe.Pointer(uintptr(ptr) + elemSize)
+		var word [8]byte
+		copy(word[8-(end-start):], bytes[start:end])
+		words[i] = C.uint64_t(binary.BigEndian.Uint64(word[:]))
+	}
+
+	sign := 0
+	if b.Sign() < 0 {
+		sign = 1
+	}
+
+	var wordsPtr *C.uint64_t
+	if len(words) > 0 {
+		wordsPtr = &words[0]
 	}
 
-	return nil
+	return C.v8_new_big_int(isolate, C.int(sign), C.int(len(words)), wordsPtr)
 }
 
-func (val Value) ToObject(res interface{}) error {
-	typeName := reflect.TypeOf(res).Elem().Name()
+func (val Value) ToObject(res interface{}, opts ...engines.DecodeOption) error {
 	value := reflect.ValueOf(res)
-	if value.Kind() != reflect.Ptr {
-		return fmt.Errorf("You must pass type %q by pointer", typeName)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("You must pass type %q by pointer", reflect.TypeOf(res))
 	}
-	return toObject(typeName, val.data, value)
+	return newDecoder(opts).decode("", val.data, value.Elem())
 }
 
 func toBoolArray(data C.struct_v8_value) ([]bool, error) {
@@ -415,18 +570,109 @@ func (val Value) ToStringArray() ([]string, error) {
 }
 
 func (val Value) ToArray() ([]interface{}, error) {
-	return nil, nil
+	if !bool(C.v8_is_array(val.data)) {
+		return nil, fmt.Errorf("Can't convert %s to []interface{}", typeToString(val.data))
+	}
+
+	res, err := toInterface("", val.data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]interface{}), nil
 }
 
-func toArray(data C.struct_v8_value, typeKind reflect.Kind, ptr unsafe.Pointer) error {
-	switch typeKind {
-	case reflect.Bool:
-		val, err := toBoolArray(data)
+func (val Value) ToMap() ([]engines.MapEntry, error) {
+	if !bool(C.v8_is_map(val.data)) {
+		return nil, fmt.Errorf("Can't convert %s to map", typeToString(val.data))
+	}
+
+	var res []engines.MapEntry
+
+	err := forEachPair(C.v8_map_to_pairs(val.data), func(pair *C.struct_v8_pair_value) error {
+		path := fmt.Sprintf("{%d}", len(res))
+
+		key, err := toInterface(path+".key", pair.first, 1)
 		if err != nil {
 			return err
 		}
-		valPtr := (*[]bool)(ptr)
-		*valPtr = val
+
+		value, err := toInterface(path+".value", pair.second, 1)
+		if err != nil {
+			return err
+		}
+
+		res = append(res, engines.MapEntry{Key: key, Value: value})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (val Value) ToStringMap() (map[string]string, error) {
+	var res map[string]string
+
+	if !bool(C.v8_is_map(val.data)) && !isPlainObject(val.data) {
+		return nil, fmt.Errorf("Can't convert %s to map[string]string", typeToString(val.data))
+	}
+
+	err := (&decoder{}).decode("", val.data, reflect.ValueOf(&res).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (val Value) ToSet() ([]interface{}, error) {
+	if !bool(C.v8_is_set(val.data)) {
+		return nil, fmt.Errorf("Can't convert %s to set", typeToString(val.data))
+	}
+
+	res, err := toInterface("", val.data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]interface{}), nil
+}
+
+// ToInterface converts the value to nil, bool, int64, float64, string,
+// []interface{}, map[string]interface{}, time.Time for dates, *big.Int
+// for BigInts, map[interface{}]interface{} for Maps or []interface{} for
+// Sets recursively, nested functions and symbols are dropped
+func (val Value) ToInterface() (interface{}, error) {
+	return toInterface("", val.data, 0)
+}
+
+func newValue(isolate *C.struct_v8_isolate, value reflect.Value, depth int) (C.struct_v8_value, error) {
+	// Go values may be cyclic through pointers, maps or slices
+	if depth > maxDepth {
+		return C.struct_v8_value{}, fmt.Errorf("Value is nested deeper than %d levels, it may be cyclic", maxDepth)
+	}
+
+	if !value.IsValid() {
+		return C.v8_new_null(isolate), nil
+	}
+
+	if value.CanInterface() {
+		switch val := value.Interface().(type) {
+		case Value:
+			return C.v8_copy_value(val.data), nil
+		case time.Time:
+			return newDate(isolate, val), nil
+		case big.Int:
+			return newBigInt(isolate, &val), nil
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return C.v8_new_bool(isolate, C.bool(value.Bool())), nil
 	case reflect.Int:
 		fallthrough
 	case reflect.Int8:
@@ -436,12 +682,7 @@ func toArray(data C.struct_v8_value, typeKind reflect.Kind, ptr unsafe.Pointer)
 	case reflect.Int32:
 		fallthrough
 	case reflect.Int64:
-		val, err := toIntArray(data)
-		if err != nil {
-			return err
-		}
-		valPtr := (*[]int64)(ptr)
-		*valPtr = val
+		return C.v8_new_int64(isolate, C.int64_t(value.Int())), nil
 	case reflect.Uint:
 		fallthrough
 	case reflect.Uint8:
@@ -451,31 +692,289 @@ func toArray(data C.struct_v8_value, typeKind reflect.Kind, ptr unsafe.Pointer)
 	case reflect.Uint32:
 		fallthrough
 	case reflect.Uint64:
-		val, err := toUintArray(data)
-		if err != nil {
-			return err
+		u := value.Uint()
+		if u > math.MaxInt64 {
+			return C.struct_v8_value{}, fmt.Errorf("Can't convert %d to int64", u)
 		}
-		valPtr := (*[]uint64)(ptr)
-		*valPtr = val
+		return C.v8_new_int64(isolate, C.int64_t(u)), nil
 	case reflect.Float32:
 		fallthrough
 	case reflect.Float64:
-		val, err := toFloatArray(data)
-		if err != nil {
-			return err
+		return C.v8_new_double(isolate, C.double(value.Float())), nil
+	case reflect.String:
+		str := value.String()
+		strPtr := C.CString(str)
+		defer C.free(unsafe.Pointer(strPtr))
+		return C.v8_new_string(isolate, strPtr, C.int(len(str))), nil
+	case reflect.Ptr:
+		fallthrough
+	case reflect.Interface:
+		if value.IsNil() {
+			return C.v8_new_null(isolate), nil
 		}
-		valPtr := (*[]float64)(ptr)
-		*valPtr = val
+		return newValue(isolate, value.Elem(), depth+1)
+	case reflect.Slice:
+		if value.IsNil() {
+			return C.v8_new_null(isolate), nil
+		}
+		return newArray(isolate, value, depth)
 	case reflect.Array:
+		return newArray(isolate, value, depth)
 	case reflect.Map:
+		if value.IsNil() {
+			return C.v8_new_null(isolate), nil
+		}
+		return newObjectFromMap(isolate, value, depth)
+	case reflect.Struct:
+		return newObjectFromStruct(isolate, value, depth)
+	}
+
+	return C.struct_v8_value{}, fmt.Errorf("Can't convert %q to JavaScript value", value.Type())
+}
+
+func deleteValues(values []C.struct_v8_value) {
+	for i := range values {
+		C.v8_delete_value(&values[i])
+	}
+}
+
+func deletePairs(pairs []C.struct_v8_pair_value) {
+	for i := range pairs {
+		C.v8_delete_value(&pairs[i].first)
+		C.v8_delete_value(&pairs[i].second)
+	}
+}
+
+func newArray(isolate *C.struct_v8_isolate, value reflect.Value, depth int) (C.struct_v8_value, error) {
+	size := value.Len()
+
+	values := make([]C.struct_v8_value, 0, size)
+	defer func() {
+		deleteValues(values)
+	}()
+
+	for i := 0; i < size; i++ {
+		val, err := newValue(isolate, value.Index(i), depth+1)
+		if err != nil {
+			return C.struct_v8_value{}, fmt.Errorf("[%d]: %s", i, err)
+		}
+		values = append(values, val)
+	}
+
+	var valuesPtr *C.struct_v8_value
+	if size > 0 {
+		valuesPtr = &values[0]
+	}
+
+	return C.v8_new_array(isolate, valuesPtr, C.int(size)), nil
+}
+
+func newObject(isolate *C.struct_v8_isolate, pairs []C.struct_v8_pair_value) C.struct_v8_value {
+	var pairsPtr *C.struct_v8_pair_value
+	if len(pairs) > 0 {
+		pairsPtr = &pairs[0]
+	}
+
+	return C.v8_new_object(isolate, pairsPtr, C.int(len(pairs)))
+}
+
+func newPair(isolate *C.struct_v8_isolate, key string, value reflect.Value, depth int) (C.struct_v8_pair_value, error) {
+	var pair C.struct_v8_pair_value
+
+	val, err := newValue(isolate, value, depth)
+	if err != nil {
+		return pair, err
+	}
+
+	keyPtr := C.CString(key)
+	defer C.free(unsafe.Pointer(keyPtr))
+
+	pair.first = C.v8_new_string(isolate, keyPtr, C.int(len(key)))
+	pair.second = val
+
+	return pair, nil
+}
+
+func mapKeyToString(key reflect.Value) (string, error) {
+	switch key.Kind() {
 	case reflect.String:
-		val, err := toStringArray(data)
+		return key.String(), nil
+	case reflect.Int:
+		fallthrough
+	case reflect.Int8:
+		fallthrough
+	case reflect.Int16:
+		fallthrough
+	case reflect.Int32:
+		fallthrough
+	case reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint:
+		fallthrough
+	case reflect.Uint8:
+		fallthrough
+	case reflect.Uint16:
+		fallthrough
+	case reflect.Uint32:
+		fallthrough
+	case reflect.Uint64:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("Map key of type %q is not supported", key.Type())
+}
+
+func newObjectFromMap(isolate *C.struct_v8_isolate, value reflect.Value, depth int) (C.struct_v8_value, error) {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+
+	entries := make([]entry, 0, value.Len())
+
+	iter := value.MapRange()
+	for iter.Next() {
+		key, err := mapKeyToString(iter.Key())
 		if err != nil {
-			return err
+			return C.struct_v8_value{}, err
+		}
+		entries = append(entries, entry{key, iter.Value()})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	pairs := make([]C.struct_v8_pair_value, 0, len(entries))
+	defer func() {
+		deletePairs(pairs)
+	}()
+
+	for _, e := range entries {
+		pair, err := newPair(isolate, e.key, e.value, depth+1)
+		if err != nil {
+			return C.struct_v8_value{}, fmt.Errorf("At [%s]: %s", e.key, err)
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return newObject(isolate, pairs), nil
+}
+
+// parseTag returns the property name of a struct field and whether it should
+// be skipped when empty, following the rules of encoding/json
+func parseTag(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	name = field.Name
+
+	tag, ok := field.Tag.Lookup("js")
+	if !ok {
+		tag, ok = field.Tag.Lookup("json")
+	}
+	if !ok {
+		return name, false, false
+	}
+
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	if len(parts[0]) > 0 {
+		name = parts[0]
+	}
+
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
 		}
-		valPtr := (*[]string)(ptr)
-		*valPtr = val
-	case reflect.Struct:
 	}
-	return nil
+
+	return name, omitEmpty, false
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return value.IsNil()
+	}
+	return false
+}
+
+func appendStructPairs(isolate *C.struct_v8_isolate, value reflect.Value, pairs []C.struct_v8_pair_value, depth int) ([]C.struct_v8_pair_value, error) {
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+
+		// like encoding/json, unexported fields are skipped, but exported
+		// fields of embedded structs are promoted
+		if len(field.PkgPath) > 0 {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if !field.Anonymous || fieldType.Kind() != reflect.Struct {
+				continue
+			}
+		}
+
+		name, omitEmpty, skip := parseTag(field)
+		if skip {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+
+		if omitEmpty && isEmptyValue(fieldValue) {
+			continue
+		}
+
+		if field.Anonymous && name == field.Name {
+			embedded := fieldValue
+			if embedded.Kind() == reflect.Ptr && embedded.Type().Elem().Kind() == reflect.Struct {
+				// fields of a nil embedded pointer are omitted like encoding/json does
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				var err error
+				pairs, err = appendStructPairs(isolate, embedded, pairs, depth+1)
+				if err != nil {
+					return pairs, err
+				}
+				continue
+			}
+		}
+
+		pair, err := newPair(isolate, name, fieldValue, depth+1)
+		if err != nil {
+			return pairs, fmt.Errorf("At %s.%s: %s", valueType.Name(), name, err)
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return pairs, nil
+}
+
+func newObjectFromStruct(isolate *C.struct_v8_isolate, value reflect.Value, depth int) (C.struct_v8_value, error) {
+	pairs, err := appendStructPairs(isolate, value, nil, depth)
+	defer deletePairs(pairs)
+
+	if err != nil {
+		return C.struct_v8_value{}, err
+	}
+
+	return newObject(isolate, pairs), nil
 }
@@ -1,8 +1,23 @@
 package engines
 
+import (
+	"math/big"
+	"time"
+)
+
+type PromiseState int
+
+const (
+	PromisePending PromiseState = iota
+	PromiseFulfilled
+	PromiseRejected
+)
+
 type Value interface {
 	Dispose()
 
+	String() string
+
 	IsUndefined() bool
 	IsBoolean() bool
 	IsNull() bool
@@ -14,19 +29,80 @@ type Value interface {
 	IsArray() bool
 	IsSet() bool
 	IsMap() bool
+	IsFunction() bool
+	IsPromise() bool
+	IsBigInt() bool
+	IsDate() bool
+	IsSymbol() bool
 
 	ToBool() (bool, error)
 	ToInt() (int64, error)
 	ToUint() (uint64, error)
 	ToFloat() (float64, error)
 	ToString() (string, error)
+	ToBigInt() (*big.Int, error)
+	// ToTime converts a Date to the local time
+	ToTime() (time.Time, error)
+	// SymbolDescription returns the description of a Symbol, it's empty
+	// for symbols created without one
+	SymbolDescription() (string, error)
 
-	ToObject(obj interface{}) error
+	// ToObject decodes the value into obj, which must be a pointer. Fields
+	// of structs are matched by the js or json tag or by the name. Like
+	// encoding/json, fields without a matching key are left untouched, so
+	// no field is required. omitempty only lets a field of a type that has
+	// no null, e.g. int, be null or undefined, such a field is left as is
+	ToObject(obj interface{}, opts ...DecodeOption) error
 
 	ToBoolArray() ([]bool, error)
 	ToIntArray() ([]int64, error)
 	ToUintArray() ([]uint64, error)
 	ToFloatArray() ([]float64, error)
 	ToStringArray() ([]string, error)
+	// ToArray converts elements of an array like ToInterface
 	ToArray() ([]interface{}, error)
+	// ToInterface converts any value that has a Go counterpart: nil, bool,
+	// int64, float64, string, []interface{}, map[string]interface{},
+	// time.Time for dates, *big.Int for BigInts, map[interface{}]interface{}
+	// for Maps and []interface{} for Sets. Nested functions and symbols are
+	// dropped as JSON.stringify does: properties are omitted, elements of
+	// arrays become nil
+	ToInterface() (interface{}, error)
+
+	// ToMap returns entries of a Map in the insertion order, keys and values
+	// are converted like ToInterface
+	ToMap() ([]MapEntry, error)
+	// ToStringMap converts a Map or an object whose keys and values are all
+	// strings
+	ToStringMap() (map[string]string, error)
+	// ToSet returns elements of a Set in the insertion order converted like
+	// ToInterface
+	ToSet() ([]interface{}, error)
+
+	ToFunction() (Function, error)
+
+	PromiseState() (PromiseState, error)
+	// PromiseResult returns the value of a fulfilled promise or the reason
+	// of a rejected one as an error
+	PromiseResult() (Value, error)
+}
+
+type MapEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// DecodeOptions change how ToObject decodes values
+type DecodeOptions struct {
+	// IgnoreUnknownKeys skips properties that match no field of a struct
+	// instead of failing
+	IgnoreUnknownKeys bool
+}
+
+type DecodeOption func(*DecodeOptions)
+
+func IgnoreUnknownKeys() DecodeOption {
+	return func(opts *DecodeOptions) {
+		opts.IgnoreUnknownKeys = true
+	}
 }
@@ -0,0 +1,263 @@
+package gojs
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+type timer struct {
+	id       int64
+	fn       engines.Function
+	args     []engines.Value
+	due      time.Time
+	interval time.Duration
+	repeat   bool
+	index    int
+}
+
+func (t *timer) dispose() {
+	t.fn.Dispose()
+	for _, arg := range t.args {
+		arg.Dispose()
+	}
+}
+
+type timerQueue []*timer
+
+func (queue timerQueue) Len() int {
+	return len(queue)
+}
+
+func (queue timerQueue) Less(i, j int) bool {
+	if queue[i].due.Equal(queue[j].due) {
+		return queue[i].id < queue[j].id
+	}
+	return queue[i].due.Before(queue[j].due)
+}
+
+func (queue timerQueue) Swap(i, j int) {
+	queue[i], queue[j] = queue[j], queue[i]
+	queue[i].index = i
+	queue[j].index = j
+}
+
+func (queue *timerQueue) Push(x interface{}) {
+	t := x.(*timer)
+	t.index = len(*queue)
+	*queue = append(*queue, t)
+}
+
+func (queue *timerQueue) Pop() interface{} {
+	old := *queue
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*queue = old[:n-1]
+	return t
+}
+
+// eventLoop keeps timers of a runner. It is used only from the goroutine of
+// the runner, the functions it installs are called from scripts executed by
+// the same goroutine
+type eventLoop struct {
+	runner engines.Runner
+	nextID int64
+	timers map[int64]*timer
+	queue  timerQueue
+	// abort stops waiting for timers when the executor is shut down
+	abort <-chan struct{}
+}
+
+func newEventLoop(runner engines.Runner) (*eventLoop, error) {
+	loop := &eventLoop{
+		runner: runner,
+		timers: make(map[int64]*timer),
+	}
+
+	funcs := []struct {
+		name string
+		fn   interface{}
+	}{
+		{"setTimeout", loop.setTimeout},
+		{"setInterval", loop.setInterval},
+		{"clearTimeout", loop.clearTimer},
+		{"clearInterval", loop.clearTimer},
+		{"queueMicrotask", loop.queueMicrotask},
+	}
+
+	for _, f := range funcs {
+		err := runner.RegisterFunc(f.name, f.fn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return loop, nil
+}
+
+func (loop *eventLoop) add(callback, delay engines.Value, args []engines.Value, repeat bool) (int64, error) {
+	if delay != nil {
+		defer delay.Dispose()
+	}
+
+	if callback == nil {
+		return 0, errors.New("callback must be a function")
+	}
+
+	fn, err := callback.ToFunction()
+	callback.Dispose()
+	if err != nil {
+		return 0, errors.New("callback must be a function")
+	}
+
+	var ms float64
+	if delay != nil && delay.IsNumber() {
+		ms, _ = delay.ToFloat()
+	}
+	if ms < 0 || math.IsNaN(ms) {
+		ms = 0
+	}
+
+	interval := time.Duration(ms * float64(time.Millisecond))
+
+	// an interval without a delay would never let the loop make progress
+	if repeat && interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+
+	loop.nextID++
+
+	t := &timer{
+		id:       loop.nextID,
+		fn:       fn,
+		args:     args,
+		due:      time.Now().Add(interval),
+		interval: interval,
+		repeat:   repeat,
+	}
+
+	loop.timers[t.id] = t
+	heap.Push(&loop.queue, t)
+
+	return t.id, nil
+}
+
+func (loop *eventLoop) setTimeout(callback, delay engines.Value, args ...engines.Value) (int64, error) {
+	return loop.add(callback, delay, args, false)
+}
+
+func (loop *eventLoop) setInterval(callback, delay engines.Value, args ...engines.Value) (int64, error) {
+	return loop.add(callback, delay, args, true)
+}
+
+func (loop *eventLoop) clearTimer(id engines.Value) {
+	if id == nil {
+		return
+	}
+
+	defer id.Dispose()
+
+	i, err := id.ToInt()
+	if err != nil {
+		return
+	}
+
+	t := loop.timers[i]
+	if t == nil {
+		return
+	}
+
+	delete(loop.timers, i)
+
+	// a timer cleared from its own callback is disposed by run
+	if t.index >= 0 {
+		heap.Remove(&loop.queue, t.index)
+		t.dispose()
+	}
+}
+
+func (loop *eventLoop) queueMicrotask(callback engines.Value) error {
+	if callback == nil {
+		return errors.New("callback must be a function")
+	}
+
+	defer callback.Dispose()
+
+	return loop.runner.EnqueueMicrotask(callback)
+}
+
+func (loop *eventLoop) pending() int {
+	return len(loop.timers)
+}
+
+func (loop *eventLoop) clear() {
+	for _, t := range loop.queue {
+		t.dispose()
+	}
+
+	loop.queue = nil
+	loop.timers = make(map[int64]*timer)
+}
+
+func (loop *eventLoop) leak(err error) error {
+	n := loop.pending()
+	loop.clear()
+	return fmt.Errorf("gojs.Executor: %d timers leaked: %w", n, err)
+}
+
+// run fires timers until none are left or ctx is done
+func (loop *eventLoop) run(ctx context.Context) error {
+	for len(loop.queue) > 0 {
+		if ctx.Err() != nil {
+			return loop.leak(ctx.Err())
+		}
+
+		next := loop.queue[0]
+
+		if wait := time.Until(next.due); wait > 0 {
+			delay := time.NewTimer(wait)
+			select {
+			case <-delay.C:
+			case <-ctx.Done():
+				delay.Stop()
+				return loop.leak(ctx.Err())
+			case <-loop.abort:
+				delay.Stop()
+				return loop.leak(ErrClosed)
+			}
+		}
+
+		heap.Pop(&loop.queue)
+
+		if !next.repeat {
+			delete(loop.timers, next.id)
+		}
+
+		res, err := next.fn.Call(next.args...)
+
+		if next.repeat && loop.timers[next.id] == next {
+			next.due = time.Now().Add(next.interval)
+			heap.Push(&loop.queue, next)
+		} else {
+			next.dispose()
+		}
+
+		if err != nil {
+			loop.clear()
+			return err
+		}
+
+		res.Dispose()
+
+		loop.runner.RunMicrotasks()
+	}
+
+	return nil
+}
@@ -1,15 +1,27 @@
 package gojs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mtrempoltsev/gojs/engines"
 	"github.com/mtrempoltsev/gojs/engines/v8"
 )
 
+// ErrHeapLimit is returned when a script exceeds the heap limit set with
+// WithHeapLimits
+var ErrHeapLimit = engines.ErrHeapLimit
+
+// ErrQueueFull is returned by TryRunAsync and TryCallAsync when the task
+// queue set with WithQueueSize is full and no runner is free
+var ErrQueueFull = errors.New("gojs.Executor: task queue is full")
+
 type Result struct {
 	Val engines.Value
 	Err error
@@ -22,13 +34,22 @@ type command int
 const (
 	run command = iota
 	callFunction
+	closeSession
 )
 
 type task struct {
-	cmd  command
-	name string
-	args []engines.Value
-	res  ResultChannel
+	ctx      context.Context
+	cmd      command
+	name     string
+	function string
+	args     []interface{}
+	session  *Session
+	await    bool
+	// try is set for tasks that are rejected with ErrQueueFull instead of
+	// waiting for a place in the queue
+	try    bool
+	res    ResultChannel
+	queued time.Time
 }
 
 type taskChannel chan *task
@@ -36,10 +57,88 @@ type taskChannel chan *task
 type scriptCtx struct {
 	script    engines.Script
 	functions map[string]engines.Function
+	executed  bool
+	// refs is the number of tasks using the script, a removed script is
+	// disposed when the last of them finishes. Both are guarded by the
+	// mutex of the runner
+	refs    int
+	removed bool
 }
 
 func (ctx *scriptCtx) run() (engines.Value, error) {
-	return ctx.script.Run()
+	res, err := ctx.script.Run()
+	if err == nil {
+		ctx.executed = true
+	}
+	return res, err
+}
+
+func (ctx *scriptCtx) getFunction(funcName string) (engines.Function, error) {
+	function := ctx.functions[funcName]
+	if function != nil {
+		return function, nil
+	}
+
+	// functions are declared by the script itself, so it must be executed
+	// at least once on this runner before we can find any of them
+	if !ctx.executed {
+		res, err := ctx.run()
+		if err != nil {
+			return nil, err
+		}
+		res.Dispose()
+	}
+
+	function, err := ctx.script.GetFunction(funcName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.functions[funcName] = function
+
+	return function, nil
+}
+
+func (ctx *scriptCtx) call(runner engines.Runner, funcName string, args []interface{}) (engines.Value, error) {
+	function, err := ctx.getFunction(funcName)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]engines.Value, 0, len(args))
+
+	defer func() {
+		for _, val := range values {
+			val.Dispose()
+		}
+	}()
+
+	for i, arg := range args {
+		if val, ok := arg.(engines.Value); ok {
+			arg = unwrap(val)
+		}
+
+		val, err := runner.NewValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("gojs.Executor: argument %d of '%s': %s", i, funcName, err)
+		}
+		values = append(values, val)
+	}
+
+	return function.Call(values...)
+}
+
+// reset moves the script to a new context, functions of the previous one
+// are disposed
+func (ctx *scriptCtx) reset() error {
+	for name, function := range ctx.functions {
+		function.Dispose()
+		delete(ctx.functions, name)
+	}
+
+	ctx.executed = false
+
+	return ctx.script.Reset()
 }
 
 func (ctx *scriptCtx) dispose() {
@@ -50,47 +149,292 @@ func (ctx *scriptCtx) dispose() {
 }
 
 type runnerCtx struct {
+	executor     *Executor
+	index        int
 	runner       engines.Runner
+	loop         *eventLoop
+	current      string
 	scripts      map[string]*scriptCtx
+	sessions     map[*Session]struct{}
 	pendingTasks taskChannel
-	mutex        sync.RWMutex
+	// ownTasks are the tasks of sessions pinned to the runner, pinned is
+	// the number of the sessions. Both are kept when the runner is replaced
+	ownTasks taskChannel
+	pinned   *int32
+	// broken gets the reason why the runner must be replaced while it's
+	// idle, e.g. it has failed to register a function
+	broken     chan error
+	statistics *runnerStats
+	// values is the number of results that callers haven't disposed yet,
+	// the isolate of a disposed runner is deleted when it drops to zero
+	values   int
+	disposed bool
+	mutex    sync.RWMutex
 }
 
 func (ctx *runnerCtx) start() {
+	defer ctx.executor.running.Done()
+
+	idle := ctx.executor.newIdleTimer()
+
 	for {
-		task := <-ctx.pendingTasks
+		var task *task
+
+		select {
+		case failure := <-ctx.broken:
+			if ctx.executor.respawn(ctx, failure) {
+				return
+			}
+			continue
+		case task = <-ctx.ownTasks:
+		case task = <-ctx.pendingTasks:
+		case <-idle.wait():
+			if ctx.executor.shrink(ctx) {
+				return
+			}
+			continue
+		case <-ctx.executor.quit:
+			return
+		}
+
+		idle.stop()
+
 		if task == nil {
 			continue
 		}
 
+		if task.cmd == closeSession {
+			task.session.dispose(ctx)
+			ctx.executor.finish(task, nil)
+			continue
+		}
+
+		if ctx.executor.aborted() {
+			ctx.executor.finish(task, &Result{Err: ErrClosed})
+			continue
+		}
+
+		if failure := ctx.process(task); failure != nil && ctx.executor.respawn(ctx, failure) {
+			return
+		}
+	}
+}
+
+// process executes the task and checks the health of the runner afterwards,
+// it returns the reason why the runner must be replaced if it has failed
+func (ctx *runnerCtx) process(task *task) (failure error) {
+	finished := false
+
+	defer func() {
+		if r := recover(); r != nil {
+			failure = fmt.Errorf("gojs.Executor: runner %d panicked: %v", ctx.index, r)
+			if !finished {
+				ctx.executor.finish(task, &Result{Err: failure})
+			}
+		}
+	}()
+
+	script, err := ctx.acquire(task)
+
+	if err != nil {
+		finished = true
+		ctx.executor.finish(task, &Result{
+			Val: nil,
+			Err: err,
+		})
+		return ctx.health()
+	}
+
+	started := time.Now()
+
+	ctx.current = task.name
+	res, err := ctx.execute(task, script)
+	ctx.current = ""
+
+	ctx.release(script)
+
+	ctx.statistics.update(ctx.runner, started.Sub(task.queued), time.Since(started))
+
+	finished = true
+	ctx.executor.finish(task, &Result{
+		Val: ctx.track(res),
+		Err: err,
+	})
+
+	return ctx.health()
+}
+
+// health returns nil if the runner can execute the next task
+func (ctx *runnerCtx) health() error {
+	if ctx.runner.IsDead() {
+		return ErrRunnerDead
+	}
+	if ctx.runner.HeapLimitReached() {
+		return ErrHeapLimit
+	}
+	return nil
+}
+
+func (ctx *runnerCtx) acquire(task *task) (*scriptCtx, error) {
+	var script *scriptCtx
+
+	if task.session != nil {
+		var err error
+		script, err = task.session.script(ctx, task.name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if script == nil {
+		script = ctx.scripts[task.name]
+		if script == nil {
+			return nil, fmt.Errorf("gojs.Executor: can't find script '%s'", task.name)
+		}
+	}
+
+	script.refs++
+
+	return script, nil
+}
+
+func (ctx *runnerCtx) release(script *scriptCtx) {
+	ctx.mutex.Lock()
+	script.refs--
+	dispose := script.removed && script.refs == 0
+	ctx.mutex.Unlock()
+
+	if dispose {
+		script.dispose()
+	}
+}
+
+// swap sets the script with the given name, nil removes it. The previous
+// script is disposed as soon as no task uses it
+func (ctx *runnerCtx) swap(scriptName string, script *scriptCtx) bool {
+	ctx.mutex.Lock()
+	old := ctx.scripts[scriptName]
+	if script != nil {
+		ctx.scripts[scriptName] = script
+	} else {
+		delete(ctx.scripts, scriptName)
+	}
+	dispose := false
+	if old != nil {
+		old.removed = true
+		dispose = old.refs == 0
+	}
+	ctx.mutex.Unlock()
+
+	if dispose {
+		old.dispose()
+	}
+
+	return old != nil
+}
+
+func (ctx *runnerCtx) execute(task *task, script *scriptCtx) (engines.Value, error) {
+	// the deadline has passed while the task was in the queue
+	if err := task.ctx.Err(); err != nil {
+		atomic.AddUint64(&ctx.executor.queue.rejectedExpired, 1)
+		return nil, err
+	}
+
+	cancel := task.ctx.Done()
+
+	var done chan struct{}
+	var terminated chan bool
+
+	if cancel != nil {
+		done = make(chan struct{})
+		terminated = make(chan bool, 1)
+
+		go func() {
+			select {
+			case <-cancel:
+				ctx.runner.Terminate()
+				terminated <- true
+			case <-done:
+				terminated <- false
+			}
+		}()
+	}
+
+	var res engines.Value
+	var err error
+
+	if ctx.executor.options.isolation && task.session == nil {
+		err = script.reset()
+	}
+
+	if err == nil {
 		switch task.cmd {
 		case run:
-			ctx.mutex.RLock()
-			script := ctx.scripts[task.name]
-			ctx.mutex.RUnlock()
-
-			if script == nil {
-				task.res <- &Result{
-					Val: nil,
-					Err: fmt.Errorf("gojs.Executor: can't find script '%s'", task.name),
-				}
-				close(task.res)
-			} else {
-				res, err := script.run()
-				task.res <- &Result{
-					Val: res,
-					Err: err,
-				}
-				close(task.res)
-			}
+			res, err = script.run()
 		case callFunction:
-			break
+			res, err = script.call(ctx.runner, task.function, task.args)
+		}
+	}
+
+	ctx.runner.RunMicrotasks()
+
+	if ctx.loop != nil {
+		if err == nil {
+			err = ctx.loop.run(task.ctx)
+			if err != nil {
+				res.Dispose()
+				res = nil
+			}
+		} else {
+			ctx.loop.clear()
+		}
+	}
+
+	if err == nil && task.await {
+		res, err = settle(res)
+	}
+
+	if cancel == nil {
+		return res, err
+	}
+
+	close(done)
+
+	if <-terminated {
+		// the termination request may arrive after the script has finished,
+		// in this case it must not affect the next task
+		ctx.runner.CancelTerminate()
+		if err != nil && !errors.Is(err, task.ctx.Err()) {
+			return nil, task.ctx.Err()
 		}
 	}
+
+	return res, err
+}
+
+func settle(val engines.Value) (engines.Value, error) {
+	if !val.IsPromise() {
+		return val, nil
+	}
+
+	defer val.Dispose()
+
+	state, err := val.PromiseState()
+	if err != nil {
+		return nil, err
+	}
+
+	if state == engines.PromisePending {
+		return nil, errors.New("gojs.Executor: promise is still pending and nothing can settle it")
+	}
+
+	return val.PromiseResult()
 }
 
-func (ctx *runnerCtx) compile(scriptName, code string) (*scriptCtx, error) {
-	script, err := ctx.runner.Compile(scriptName, code)
+func newScriptCtx(script engines.Script, err error) (*scriptCtx, error) {
 	if err != nil {
 		return nil, err
 	}
@@ -101,35 +445,182 @@ func (ctx *runnerCtx) compile(scriptName, code string) (*scriptCtx, error) {
 	}, nil
 }
 
+func (ctx *runnerCtx) compile(scriptName string, src source) (*scriptCtx, bool, error) {
+	if src.module {
+		script, err := newScriptCtx(ctx.runner.CompileModule(scriptName, src.code, ctx.executor.options.moduleLoader))
+		return script, false, err
+	}
+
+	script, rejected, err := ctx.runner.CompileWithCache(scriptName, src.code, src.cache)
+	if err != nil {
+		return nil, rejected, err
+	}
+
+	res, err := newScriptCtx(script, nil)
+	return res, rejected, err
+}
+
+// codeCache makes the code cache of a loaded script, the script isn't disposed
+// until the cache is made
+func (ctx *runnerCtx) codeCache(scriptName string) ([]byte, error) {
+	ctx.mutex.Lock()
+	script := ctx.scripts[scriptName]
+	if script != nil {
+		script.refs++
+	}
+	ctx.mutex.Unlock()
+
+	if script == nil {
+		return nil, fmt.Errorf("can't find script '%s'", scriptName)
+	}
+
+	defer ctx.release(script)
+
+	return script.script.CodeCache()
+}
+
 func (ctx *runnerCtx) dispose() {
+	if ctx.loop != nil {
+		ctx.loop.clear()
+	}
 	for _, script := range ctx.scripts {
 		script.dispose()
 	}
-	ctx.runner.Dispose()
+	for session := range ctx.sessions {
+		session.release(ctx)
+	}
+
+	ctx.mutex.Lock()
+	ctx.disposed = true
+	values := ctx.values
+	ctx.mutex.Unlock()
+
+	if values == 0 {
+		ctx.runner.Dispose()
+	}
+}
+
+type source struct {
+	code   string
+	module bool
+	// cache is the code cache passed to CompileWithCache if it has been
+	// accepted or the one made by the first runner, so the other runners
+	// and the ones created later don't compile the code from scratch
+	cache []byte
+}
+
+type registeredFunc struct {
+	name string
+	fn   interface{}
 }
 
 type Executor struct {
+	// queue and health are the first fields to keep their 64-bit counters
+	// aligned
+	queue        queueCounters
+	health       healthCounters
+	options      options
 	engine       engines.Engine
 	pendingTasks taskChannel
 	runners      []*runnerCtx
+	// sources and funcs are kept to set up runners created to replace
+	// broken ones, mutex guards them together with runners
+	sources map[string]source
+	funcs   []registeredFunc
+	mutex   sync.Mutex
+
+	minRunners int
+	maxRunners int
+	// waiting is the number of tasks that wait for a free runner, growing
+	// is set while a new runner is being created
+	waiting int32
+	growing int32
+
+	nextSession uint32
+
+	// closed is set by Shutdown holding both mutex and lifecycle, so it can
+	// be read holding either of them. tasks counts accepted tasks that
+	// aren't finished yet, running counts goroutines of runners
+	closed    bool
+	lifecycle sync.RWMutex
+	tasks     sync.WaitGroup
+	running   sync.WaitGroup
+	// done is closed when Shutdown starts, abort when it stops waiting for
+	// accepted tasks and quit when runners must exit
+	done  chan struct{}
+	abort chan struct{}
+	quit  chan struct{}
 }
 
-func (executor *Executor) newRunner() (*runnerCtx, error) {
-	runner, err := executor.engine.NewRunner()
+func (executor *Executor) newRunner(index int) (*runnerCtx, error) {
+	runner, err := executor.engine.NewRunner(engines.RunnerOptions{
+		MaxOldGenerationSize:   executor.options.maxOldGenerationSize,
+		MaxYoungGenerationSize: executor.options.maxYoungGenerationSize,
+		Snapshot:               executor.options.snapshot,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	instance := &runnerCtx{
+		executor:     executor,
+		index:        index,
 		runner:       runner,
 		pendingTasks: executor.pendingTasks,
 		scripts:      make(map[string]*scriptCtx),
+		sessions:     make(map[*Session]struct{}),
+		ownTasks:     make(taskChannel),
+		pinned:       new(int32),
+		broken:       make(chan error, 1),
+		statistics:   newRunnerStats(),
+	}
+
+	instance.statistics.heap = runner.HeapStatistics()
+
+	err = installConsole(instance, executor.options.console)
+	if err != nil {
+		runner.Dispose()
+		return nil, err
+	}
+
+	if executor.options.eventLoop {
+		instance.loop, err = newEventLoop(runner)
+		if err != nil {
+			runner.Dispose()
+			return nil, err
+		}
+		instance.loop.abort = executor.abort
+	}
+
+	if executor.options.requireLoader != nil {
+		err = installRequire(instance, executor.options.requireLoader)
+		if err != nil {
+			runner.Dispose()
+			return nil, err
+		}
+	}
+
+	for _, f := range executor.funcs {
+		err = runner.RegisterFunc(f.name, f.fn)
+		if err != nil {
+			instance.dispose()
+			return nil, err
+		}
+	}
+
+	for name, src := range executor.sources {
+		script, _, err := instance.compile(name, src)
+		if err != nil {
+			instance.dispose()
+			return nil, err
+		}
+		instance.scripts[name] = script
 	}
 
 	return instance, nil
 }
 
-func New(runnersNum int) (*Executor, error) {
+func New(runnersNum int, opts ...Option) (*Executor, error) {
 	if runnersNum < 0 {
 		return nil, errors.New(
 			"gojs.Executor.New: number of runners must be a positive number " +
@@ -140,19 +631,37 @@ func New(runnersNum int) (*Executor, error) {
 		runnersNum = runtime.NumCPU()
 	}
 
-	engine, err := v8.New(runnersNum)
+	options := newOptions(opts)
+
+	maxRunners := runnersNum
+	if options.maxRunners != 0 {
+		if options.maxRunners < runnersNum {
+			return nil, errors.New(
+				"gojs.Executor.New: maximum number of runners must not be less than the initial number")
+		}
+		maxRunners = options.maxRunners
+	}
+
+	engine, err := v8.New(maxRunners)
 	if err != nil {
 		return nil, err
 	}
 
 	instance := Executor{
+		options:      options,
+		minRunners:   runnersNum,
+		maxRunners:   maxRunners,
 		engine:       engine,
-		pendingTasks: make(taskChannel),
+		pendingTasks: make(taskChannel, options.queueSize),
 		runners:      make([]*runnerCtx, runnersNum),
+		sources:      make(map[string]source),
+		done:         make(chan struct{}),
+		abort:        make(chan struct{}),
+		quit:         make(chan struct{}),
 	}
 
 	for i := 0; i < runnersNum; i++ {
-		instance.runners[i], err = instance.newRunner()
+		instance.runners[i], err = instance.newRunner(i)
 		if err != nil {
 			for j := 0; j < i; j++ {
 				instance.runners[j].dispose()
@@ -163,7 +672,7 @@ func New(runnersNum int) (*Executor, error) {
 	}
 
 	for _, runner := range instance.runners {
-		go runner.start()
+		instance.start(runner)
 	}
 
 	return &instance, nil
@@ -178,6 +687,49 @@ func (executor *Executor) Compile(scriptName, code string) error {
 		return errors.New("gojs.Executor.Compile: code is empty, nothing to compile")
 	}
 
+	_, err := executor.compile(scriptName, source{code: code}, false)
+	return err
+}
+
+// CompileModule compiles code as an ES module. Imports are resolved with the
+// loader passed to WithModuleLoader. Run evaluates the module and returns its
+// namespace, Call invokes its exported functions
+func (executor *Executor) CompileModule(scriptName, code string) error {
+	if len(scriptName) == 0 {
+		return errors.New("gojs.Executor.CompileModule: you must specify scriptID")
+	}
+
+	if len(code) == 0 {
+		return errors.New("gojs.Executor.CompileModule: code is empty, nothing to compile")
+	}
+
+	if executor.options.isolation {
+		return errors.New("gojs.Executor.CompileModule: modules can't be compiled in the isolation mode")
+	}
+
+	_, err := executor.compile(scriptName, source{code: code, module: true}, false)
+	return err
+}
+
+// compile compiles the script in all runners. With replace the script must
+// be already loaded, it's checked under the same lock as the swap, so
+// a concurrent Remove can't be undone
+func (executor *Executor) compile(scriptName string, src source, replace bool) (bool, error) {
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+
+	if executor.closed {
+		return false, ErrClosed
+	}
+
+	if replace {
+		old, ok := executor.sources[scriptName]
+		if !ok {
+			return false, fmt.Errorf("gojs.Executor.Replace: can't find script '%s'", scriptName)
+		}
+		src.module = old.module
+	}
+
 	type results struct {
 		index  int
 		script *scriptCtx
@@ -185,23 +737,51 @@ func (executor *Executor) Compile(scriptName, code string) error {
 	}
 
 	n := len(executor.runners)
+	if n == 0 {
+		return false, errors.New("gojs.Executor: all runners have failed, nothing can compile the script")
+	}
+
+	scripts := make([]*scriptCtx, n)
+
+	accepted := false
+
+	// the first runner checks the cache or makes a new one, so the others
+	// and runners created later compile with a cache that is known to be
+	// accepted
+	first := 0
+	if !src.module {
+		script, rejected, err := executor.runners[0].compile(scriptName, src)
+		if err != nil {
+			return false, err
+		}
+
+		scripts[0] = script
+		first = 1
+
+		accepted = len(src.cache) > 0 && !rejected
+
+		if !accepted {
+			src.cache, err = script.script.CodeCache()
+			if err != nil {
+				src.cache = nil
+			}
+		}
+	}
 
 	channel := make(chan results, n)
 
 	defer close(channel)
 
-	for i := 0; i < n; i++ {
+	for i := first; i < n; i++ {
 		go func(i int) {
-			script, err := executor.runners[i].compile(scriptName, code)
+			script, _, err := executor.runners[i].compile(scriptName, src)
 			channel <- results{i, script, err}
 		}(i)
 	}
 
-	scripts := make([]*scriptCtx, n)
-
 	var err error
 
-	for i := 0; i < n; i++ {
+	for i := first; i < n; i++ {
 		res := <-channel
 		if res.err != nil {
 			err = res.err
@@ -216,37 +796,222 @@ func (executor *Executor) Compile(scriptName, code string) error {
 				scripts[i].dispose()
 			}
 		}
-		return err
+		return false, err
 	}
 
 	for i := 0; i < n; i++ {
-		runner := executor.runners[i]
-		runner.mutex.Lock()
-		runner.scripts[scriptName] = scripts[i]
-		runner.mutex.Unlock()
+		executor.runners[i].swap(scriptName, scripts[i])
 	}
 
-	return nil
+	executor.sources[scriptName] = src
+
+	return accepted, nil
 }
 
-func (executor *Executor) RunAsync(scriptName string) (ResultChannel, error) {
+// CompileWithCache compiles a script using a cache returned by CodeCache,
+// possibly by another process. A cache that was made for different code or
+// by a different version of the engine is rejected, in that case the code
+// is compiled from scratch and CompileWithCache returns false, so the cache
+// must be exported again
+func (executor *Executor) CompileWithCache(scriptName, code string, cache []byte) (bool, error) {
 	if len(scriptName) == 0 {
-		return nil, errors.New("gojs.Executor.Run: you must specify scriptID")
+		return false, errors.New("gojs.Executor.CompileWithCache: you must specify scriptID")
+	}
+
+	if len(code) == 0 {
+		return false, errors.New("gojs.Executor.CompileWithCache: code is empty, nothing to compile")
 	}
 
-	res := make(ResultChannel)
+	return executor.compile(scriptName, source{code: code, cache: cache}, false)
+}
+
+// CodeCache makes the code cache of a script for CompileWithCache, modules
+// have no cache. The cache isn't kept by the executor, so it's made again on
+// every call
+func (executor *Executor) CodeCache(scriptName string) ([]byte, error) {
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+
+	if executor.closed {
+		return nil, ErrClosed
+	}
+
+	src, ok := executor.sources[scriptName]
+	if !ok {
+		return nil, fmt.Errorf("gojs.Executor.CodeCache: can't find script '%s'", scriptName)
+	}
 
-	executor.pendingTasks <- &task{
-		cmd:  run,
-		name: scriptName,
-		res:  res,
+	if src.module {
+		return nil, fmt.Errorf("gojs.Executor.CodeCache: script '%s' is a module, modules have no code cache", scriptName)
 	}
 
-	return res, nil
+	if len(executor.runners) == 0 {
+		return nil, errors.New("gojs.Executor.CodeCache: all runners have failed, nothing can make the cache")
+	}
+
+	cache, err := executor.runners[0].codeCache(scriptName)
+	if err != nil {
+		return nil, fmt.Errorf("gojs.Executor.CodeCache: %s", err)
+	}
+
+	return cache, nil
 }
 
-func (executor *Executor) Run(scriptName string) (engines.Value, error) {
-	future, err := executor.RunAsync(scriptName)
+// Replace atomically replaces the code of a loaded script. The new code is
+// compiled by every runner before any of them switches to it, tasks that are
+// already running finish with the old code
+func (executor *Executor) Replace(scriptName, code string) error {
+	if len(code) == 0 {
+		return errors.New("gojs.Executor.Replace: code is empty, nothing to compile")
+	}
+
+	_, err := executor.compile(scriptName, source{code: code}, true)
+	return err
+}
+
+// Remove unloads the script from all runners, tasks that are already running
+// finish normally
+func (executor *Executor) Remove(scriptName string) error {
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+
+	if executor.closed {
+		return ErrClosed
+	}
+
+	if _, ok := executor.sources[scriptName]; !ok {
+		return fmt.Errorf("gojs.Executor.Remove: can't find script '%s'", scriptName)
+	}
+
+	delete(executor.sources, scriptName)
+
+	for _, runner := range executor.runners {
+		runner.swap(scriptName, nil)
+	}
+
+	return nil
+}
+
+// Scripts returns sorted names of loaded scripts
+func (executor *Executor) Scripts() []string {
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+
+	names := make([]string, 0, len(executor.sources))
+	for name := range executor.sources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// RegisterFunc installs fn as a global function with the given name in every
+// runner. Arguments are converted to the types of fn parameters, a non-nil
+// error returned by fn is thrown as a JavaScript exception. If fn can't be
+// installed in a runner other than the first one, e.g. because a script has
+// set a global that conflicts with the name, the function is registered and
+// the runner is replaced with a new one
+func (executor *Executor) RegisterFunc(name string, fn interface{}) error {
+	if len(name) == 0 {
+		return errors.New("gojs.Executor.RegisterFunc: you must specify function name")
+	}
+
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+
+	if executor.closed {
+		return ErrClosed
+	}
+
+	// the function is recorded first, so runners created later agree with
+	// the ones that have already got it. A bad signature fails on the first
+	// runner and then nothing is recorded
+	executor.funcs = append(executor.funcs, registeredFunc{name, fn})
+
+	for i, runner := range executor.runners {
+		err := runner.runner.RegisterFunc(name, fn)
+		if err == nil {
+			continue
+		}
+
+		if i == 0 {
+			executor.funcs = executor.funcs[:len(executor.funcs)-1]
+			return fmt.Errorf("gojs.Executor.RegisterFunc: %s", err)
+		}
+
+		// the runner respawns itself as soon as it's idle, the new one gets
+		// all recorded functions
+		select {
+		case runner.broken <- fmt.Errorf("gojs.Executor.RegisterFunc: runner %d: %s", runner.index, err):
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (executor *Executor) submit(ctx context.Context, t *task) (ResultChannel, error) {
+	executor.lifecycle.RLock()
+	if executor.closed {
+		executor.lifecycle.RUnlock()
+		return nil, ErrClosed
+	}
+	executor.tasks.Add(1)
+	executor.lifecycle.RUnlock()
+
+	t.ctx = ctx
+	// the result is buffered, so a runner never waits for the caller
+	t.res = make(ResultChannel, 1)
+	t.queued = time.Now()
+
+	tasks := executor.pendingTasks
+	if t.session != nil {
+		tasks = t.session.tasks
+	}
+
+	select {
+	case tasks <- t:
+		if t.session == nil && len(tasks) > 0 {
+			executor.grow(false)
+		}
+		return t.res, nil
+	default:
+	}
+
+	// all runners are busy and the queue is full
+	if t.try {
+		if t.session == nil {
+			executor.grow(true)
+		}
+		executor.tasks.Done()
+		atomic.AddUint64(&executor.queue.rejectedFull, 1)
+		return nil, ErrQueueFull
+	}
+
+	if t.session == nil {
+		// the task must be counted before grow checks whether a new runner
+		// is needed
+		atomic.AddInt32(&executor.waiting, 1)
+		defer atomic.AddInt32(&executor.waiting, -1)
+		executor.grow(false)
+	}
+
+	select {
+	case tasks <- t:
+		return t.res, nil
+	case <-ctx.Done():
+		executor.tasks.Done()
+		atomic.AddUint64(&executor.queue.rejectedExpired, 1)
+		return nil, ctx.Err()
+	case <-executor.done:
+		executor.tasks.Done()
+		return nil, ErrClosed
+	}
+}
+
+func wait(future ResultChannel, err error) (engines.Value, error) {
 	if err != nil {
 		return nil, err
 	}
@@ -256,9 +1021,89 @@ func (executor *Executor) Run(scriptName string) (engines.Value, error) {
 	return res.Val, res.Err
 }
 
-func (executor *Executor) Dispose() {
-	for _, runner := range executor.runners {
-		runner.dispose()
+func (executor *Executor) runAsync(ctx context.Context, scriptName string, await, try bool) (ResultChannel, error) {
+	if len(scriptName) == 0 {
+		return nil, errors.New("gojs.Executor.Run: you must specify scriptID")
+	}
+
+	return executor.submit(ctx, &task{
+		cmd:   run,
+		name:  scriptName,
+		await: await,
+		try:   try,
+	})
+}
+
+func (executor *Executor) RunAsync(scriptName string) (ResultChannel, error) {
+	return executor.runAsync(context.Background(), scriptName, false, false)
+}
+
+// TryRunAsync runs the script like RunAsync, but returns ErrQueueFull instead
+// of waiting if the queue is full. The script is terminated if ctx is done
+// before it has finished
+func (executor *Executor) TryRunAsync(ctx context.Context, scriptName string) (ResultChannel, error) {
+	return executor.runAsync(ctx, scriptName, false, true)
+}
+
+func (executor *Executor) Run(scriptName string) (engines.Value, error) {
+	return wait(executor.runAsync(context.Background(), scriptName, false, false))
+}
+
+// RunContext runs the script like Run, but terminates it as soon as ctx is
+// done and returns ctx.Err() in that case
+func (executor *Executor) RunContext(ctx context.Context, scriptName string) (engines.Value, error) {
+	return wait(executor.runAsync(ctx, scriptName, false, false))
+}
+
+// RunAwait runs the script like RunContext and, if the result is a promise,
+// waits until it is settled. A rejected promise is returned as an error
+func (executor *Executor) RunAwait(ctx context.Context, scriptName string) (engines.Value, error) {
+	return wait(executor.runAsync(ctx, scriptName, true, false))
+}
+
+func (executor *Executor) callAsync(ctx context.Context, scriptName, funcName string, args []interface{}, await, try bool) (ResultChannel, error) {
+	if len(scriptName) == 0 {
+		return nil, errors.New("gojs.Executor.Call: you must specify scriptID")
 	}
-	executor.engine.Dispose()
+
+	if len(funcName) == 0 {
+		return nil, errors.New("gojs.Executor.Call: you must specify function name")
+	}
+
+	return executor.submit(ctx, &task{
+		cmd:      callFunction,
+		name:     scriptName,
+		function: funcName,
+		args:     args,
+		await:    await,
+		try:      try,
+	})
+}
+
+func (executor *Executor) CallAsync(scriptName, funcName string, args ...interface{}) (ResultChannel, error) {
+	return executor.callAsync(context.Background(), scriptName, funcName, args, false, false)
+}
+
+// TryCallAsync calls the function like CallAsync, but returns ErrQueueFull
+// instead of waiting if the queue is full. The function is terminated if ctx
+// is done before it has finished
+func (executor *Executor) TryCallAsync(ctx context.Context, scriptName, funcName string, args ...interface{}) (ResultChannel, error) {
+	return executor.callAsync(ctx, scriptName, funcName, args, false, true)
+}
+
+func (executor *Executor) Call(scriptName, funcName string, args ...interface{}) (engines.Value, error) {
+	return wait(executor.callAsync(context.Background(), scriptName, funcName, args, false, false))
+}
+
+// CallContext calls the function like Call, but terminates it as soon as ctx
+// is done and returns ctx.Err() in that case
+func (executor *Executor) CallContext(ctx context.Context, scriptName, funcName string, args ...interface{}) (engines.Value, error) {
+	return wait(executor.callAsync(ctx, scriptName, funcName, args, false, false))
+}
+
+// CallAwait calls the function like CallContext and, if the result is
+// a promise, waits until it is settled. A rejected promise is returned as
+// an error
+func (executor *Executor) CallAwait(ctx context.Context, scriptName, funcName string, args ...interface{}) (engines.Value, error) {
+	return wait(executor.callAsync(ctx, scriptName, funcName, args, true, false))
 }
@@ -0,0 +1,107 @@
+package gojs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// FSLoader resolves modules from a file system like Node.js does: relative
+// specifiers are resolved against the importing module, bare specifiers are
+// looked up in node_modules directories. It can be used both for ES modules
+// and for require
+type FSLoader struct {
+	fsys fs.FS
+}
+
+func NewFSLoader(fsys fs.FS) *FSLoader {
+	return &FSLoader{fsys}
+}
+
+func (loader *FSLoader) readFile(name string) (string, bool) {
+	if !fs.ValidPath(name) {
+		return "", false
+	}
+
+	data, err := fs.ReadFile(loader.fsys, name)
+	if err != nil {
+		return "", false
+	}
+
+	return string(data), true
+}
+
+func (loader *FSLoader) loadFile(name string) (string, string, bool) {
+	for _, candidate := range []string{name, name + ".js", name + ".json"} {
+		if info, err := fs.Stat(loader.fsys, candidate); err != nil || info.IsDir() {
+			continue
+		}
+		if source, ok := loader.readFile(candidate); ok {
+			return candidate, source, true
+		}
+	}
+	return "", "", false
+}
+
+func (loader *FSLoader) loadDirectory(name string) (string, string, bool) {
+	if pkg, ok := loader.readFile(path.Join(name, "package.json")); ok {
+		var manifest struct {
+			Main string `json:"main"`
+		}
+		if json.Unmarshal([]byte(pkg), &manifest) == nil && len(manifest.Main) > 0 {
+			main := path.Join(name, manifest.Main)
+			if id, source, ok := loader.loadFile(main); ok {
+				return id, source, true
+			}
+			if id, source, ok := loader.loadFile(path.Join(main, "index")); ok {
+				return id, source, true
+			}
+		}
+	}
+
+	return loader.loadFile(path.Join(name, "index"))
+}
+
+func (loader *FSLoader) load(name string) (string, string, bool) {
+	if id, source, ok := loader.loadFile(name); ok {
+		return id, source, true
+	}
+	return loader.loadDirectory(name)
+}
+
+func (loader *FSLoader) Resolve(specifier, referrer string) (string, string, error) {
+	if len(specifier) == 0 {
+		return "", "", errors.New("gojs.FSLoader: empty module specifier")
+	}
+
+	dir := path.Dir(referrer)
+
+	if strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") ||
+		strings.HasPrefix(specifier, "/") || specifier == "." || specifier == ".." {
+		var name string
+		if strings.HasPrefix(specifier, "/") {
+			name = path.Clean(specifier[1:])
+		} else {
+			name = path.Join(dir, specifier)
+		}
+
+		if id, source, ok := loader.load(name); ok {
+			return id, source, nil
+		}
+	} else {
+		for {
+			if id, source, ok := loader.load(path.Join(dir, "node_modules", specifier)); ok {
+				return id, source, nil
+			}
+			if dir == "." || dir == "/" || len(dir) == 0 {
+				break
+			}
+			dir = path.Dir(dir)
+		}
+	}
+
+	return "", "", fmt.Errorf("gojs.FSLoader: can't find module '%s' imported from '%s'", specifier, referrer)
+}
@@ -0,0 +1,172 @@
+package gojs
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Labels map[string]string
+
+// MetricsWriter receives metrics collected by Executor.CollectMetrics. All
+// samples of a metric are written one after another
+type MetricsWriter interface {
+	Gauge(name, help string, labels Labels, value float64)
+	Counter(name, help string, labels Labels, value float64)
+	Histogram(name, help string, labels Labels, h Histogram)
+}
+
+// CollectMetrics writes statistics of all runners, of the task queue and of
+// the health of the pool to w
+func (executor *Executor) CollectMetrics(w MetricsWriter) {
+	stats := executor.Stats()
+
+	labels := make([]Labels, len(stats))
+	for i, s := range stats {
+		labels[i] = Labels{"runner": strconv.Itoa(s.Runner)}
+	}
+
+	gauges := []struct {
+		name  string
+		help  string
+		value func(s *RunnerStats) float64
+	}{
+		{"gojs_heap_used_bytes", "Used heap size of the runner.",
+			func(s *RunnerStats) float64 { return float64(s.Heap.UsedHeapSize) }},
+		{"gojs_heap_total_bytes", "Total heap size of the runner.",
+			func(s *RunnerStats) float64 { return float64(s.Heap.TotalHeapSize) }},
+		{"gojs_heap_limit_bytes", "Heap size limit of the runner.",
+			func(s *RunnerStats) float64 { return float64(s.Heap.HeapSizeLimit) }},
+		{"gojs_external_memory_bytes", "Memory allocated outside of the heap of the runner.",
+			func(s *RunnerStats) float64 { return float64(s.Heap.ExternalMemory) }},
+		{"gojs_scripts", "Number of scripts compiled by the runner.",
+			func(s *RunnerStats) float64 { return float64(s.Scripts) }},
+	}
+
+	for _, g := range gauges {
+		for i := range stats {
+			w.Gauge(g.name, g.help, labels[i], g.value(&stats[i]))
+		}
+	}
+
+	for i := range stats {
+		w.Counter("gojs_tasks_total", "Number of tasks executed by the runner.",
+			labels[i], float64(stats[i].TasksExecuted))
+	}
+
+	for i := range stats {
+		w.Histogram("gojs_queue_wait_seconds", "Time tasks waited for the runner.",
+			labels[i], stats[i].QueueWait)
+	}
+
+	for i := range stats {
+		w.Histogram("gojs_execution_seconds", "Time the runner spent executing tasks.",
+			labels[i], stats[i].ExecutionTime)
+	}
+
+	queue := executor.QueueStats()
+
+	w.Gauge("gojs_queue_length", "Number of tasks waiting for a free runner.",
+		Labels{}, float64(queue.Length))
+	w.Gauge("gojs_queue_capacity", "Capacity of the task queue.",
+		Labels{}, float64(queue.Capacity))
+
+	health := executor.Health()
+
+	w.Gauge("gojs_runners", "Number of runners in the pool.",
+		Labels{}, float64(health.Runners))
+	w.Counter("gojs_runner_failures_total", "Number of runners that have failed.",
+		Labels{}, float64(health.Failures))
+	w.Counter("gojs_runner_respawns_total", "Number of failed runners that have been replaced.",
+		Labels{}, float64(health.Respawns))
+
+	rejected := "Number of tasks rejected before a runner started them."
+	w.Counter("gojs_tasks_rejected_total", rejected,
+		Labels{"reason": "queue_full"}, float64(queue.RejectedFull))
+	w.Counter("gojs_tasks_rejected_total", rejected,
+		Labels{"reason": "expired"}, float64(queue.RejectedExpired))
+}
+
+// PrometheusWriter writes metrics in the Prometheus text exposition format
+type PrometheusWriter struct {
+	w        io.Writer
+	lastName string
+	err      error
+}
+
+func NewPrometheusWriter(w io.Writer) *PrometheusWriter {
+	return &PrometheusWriter{w: w}
+}
+
+// Err returns the first error that occurred while writing
+func (p *PrometheusWriter) Err() error {
+	return p.err
+}
+
+func (p *PrometheusWriter) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+func (p *PrometheusWriter) header(name, help, metricType string) {
+	if name == p.lastName {
+		return
+	}
+	p.lastName = name
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels Labels, extra ...string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, key, labelEscaper.Replace(labels[key])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, extra[i], extra[i+1]))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func (p *PrometheusWriter) Gauge(name, help string, labels Labels, value float64) {
+	p.header(name, help, "gauge")
+	p.printf("%s%s %s\n", name, formatLabels(labels), formatFloat(value))
+}
+
+func (p *PrometheusWriter) Counter(name, help string, labels Labels, value float64) {
+	p.header(name, help, "counter")
+	p.printf("%s%s %s\n", name, formatLabels(labels), formatFloat(value))
+}
+
+func (p *PrometheusWriter) Histogram(name, help string, labels Labels, h Histogram) {
+	p.header(name, help, "histogram")
+
+	var cumulative uint64
+	for i, bound := range h.Bounds {
+		cumulative += h.Counts[i]
+		p.printf("%s_bucket%s %d\n", name, formatLabels(labels, "le", formatFloat(bound)), cumulative)
+	}
+	p.printf("%s_bucket%s %d\n", name, formatLabels(labels, "le", "+Inf"), h.Count)
+	p.printf("%s_sum%s %s\n", name, formatLabels(labels), formatFloat(h.Sum))
+	p.printf("%s_count%s %d\n", name, formatLabels(labels), h.Count)
+}
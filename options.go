@@ -0,0 +1,132 @@
+package gojs
+
+import (
+	"time"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+type options struct {
+	eventLoop     bool
+	console       ConsoleHandler
+	moduleLoader  engines.ModuleLoader
+	requireLoader engines.ModuleLoader
+
+	maxOldGenerationSize   int
+	maxYoungGenerationSize int
+
+	snapshot []byte
+
+	isolation bool
+
+	maxRunners  int
+	idleTimeout time.Duration
+
+	queueSize int
+
+	runnerEvents func(RunnerEvent)
+}
+
+// Option changes the default configuration of an Executor created by New
+type Option func(*options)
+
+func newOptions(opts []Option) options {
+	var res options
+	for _, opt := range opts {
+		opt(&res)
+	}
+	return res
+}
+
+// WithEventLoop installs setTimeout, setInterval, clearTimeout, clearInterval
+// and queueMicrotask into every runner. A task is considered finished only
+// when all timers it has scheduled have fired or have been cleared, so use
+// RunContext or CallContext to limit tasks that keep intervals alive
+func WithEventLoop() Option {
+	return func(opts *options) {
+		opts.eventLoop = true
+	}
+}
+
+// WithConsole routes output of the console object to handler instead of
+// the standard logger. The handler is called from runner goroutines, so it
+// must be safe for concurrent use
+func WithConsole(handler ConsoleHandler) Option {
+	return func(opts *options) {
+		opts.console = handler
+	}
+}
+
+// WithModuleLoader sets the loader used to resolve imports of modules
+// compiled by CompileModule
+func WithModuleLoader(loader engines.ModuleLoader) Option {
+	return func(opts *options) {
+		opts.moduleLoader = loader
+	}
+}
+
+// WithRequire installs the CommonJS require function into every runner, the
+// modules are loaded with loader, for example NewFSLoader(os.DirFS(dir))
+func WithRequire(loader engines.ModuleLoader) Option {
+	return func(opts *options) {
+		opts.requireLoader = loader
+	}
+}
+
+// WithHeapLimits limits the heap of every runner, sizes are in bytes and
+// zero keeps the engine default. A script that exceeds the limit is
+// terminated with ErrHeapLimit and its runner is replaced with a new one
+func WithHeapLimits(maxOldGenerationSize, maxYoungGenerationSize int) Option {
+	return func(opts *options) {
+		opts.maxOldGenerationSize = maxOldGenerationSize
+		opts.maxYoungGenerationSize = maxYoungGenerationSize
+	}
+}
+
+// WithSnapshot creates runners from a startup snapshot made by BuildSnapshot
+func WithSnapshot(snapshot []byte) Option {
+	return func(opts *options) {
+		opts.snapshot = snapshot
+	}
+}
+
+// WithIsolation makes every Run and Call execute in a new context, so
+// a task can't observe globals changed by previous tasks. The context is
+// created from the snapshot set with WithSnapshot if any. Modules can't be
+// compiled in this mode
+func WithIsolation() Option {
+	return func(opts *options) {
+		opts.isolation = true
+	}
+}
+
+// WithDynamicPool lets the pool grow from the number of runners passed to New
+// up to maxRunners when all runners are busy. A runner added this way is
+// removed after it has been idle for idleTimeout, zero keeps it forever.
+// Runners with open sessions are never removed, the isolate of a removed
+// runner is deleted when all values it has returned are disposed
+func WithDynamicPool(maxRunners int, idleTimeout time.Duration) Option {
+	return func(opts *options) {
+		opts.maxRunners = maxRunners
+		opts.idleTimeout = idleTimeout
+	}
+}
+
+// WithQueueSize sets the number of tasks that can wait for a free runner.
+// When the queue is full RunAsync and CallAsync block the caller, while
+// TryRunAsync and TryCallAsync return ErrQueueFull. The default is zero, so
+// a task is accepted only by a free runner
+func WithQueueSize(size int) Option {
+	return func(opts *options) {
+		opts.queueSize = size
+	}
+}
+
+// WithRunnerEvents calls handler when a runner fails and when it's respawned.
+// The handler is called from goroutines of the runners, so it must be safe
+// for concurrent use and must not block
+func WithRunnerEvents(handler func(RunnerEvent)) Option {
+	return func(opts *options) {
+		opts.runnerEvents = handler
+	}
+}
@@ -0,0 +1,128 @@
+package gojs
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// grow adds a runner to the pool in the background if the pool is dynamic
+// and it isn't full yet. A runner is added only if tasks are still waiting
+// for it, unless force is set for a task that was rejected because the queue
+// was full
+func (executor *Executor) grow(force bool) {
+	if executor.maxRunners == executor.minRunners {
+		return
+	}
+
+	if !atomic.CompareAndSwapInt32(&executor.growing, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&executor.growing, 0)
+
+		executor.mutex.Lock()
+		defer executor.mutex.Unlock()
+
+		if executor.closed || len(executor.runners) >= executor.maxRunners {
+			return
+		}
+
+		if !force && atomic.LoadInt32(&executor.waiting) == 0 && len(executor.pendingTasks) == 0 {
+			return
+		}
+
+		runner, err := executor.newRunner(executor.freeIndex())
+		if err != nil {
+			return
+		}
+
+		executor.runners = append(executor.runners, runner)
+
+		executor.start(runner)
+	}()
+}
+
+// shrink removes an idle runner from the pool, it's called from the goroutine
+// of the runner, which must exit if the runner was removed
+func (executor *Executor) shrink(runner *runnerCtx) bool {
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+
+	if executor.closed || len(executor.runners) <= executor.minRunners || atomic.LoadInt32(runner.pinned) > 0 {
+		return false
+	}
+
+	i := executor.position(runner)
+	if i < 0 {
+		return false
+	}
+
+	executor.runners = append(executor.runners[:i], executor.runners[i+1:]...)
+	runner.dispose()
+
+	return true
+}
+
+// position returns the position of the runner in the pool or -1
+func (executor *Executor) position(runner *runnerCtx) int {
+	for i, r := range executor.runners {
+		if r == runner {
+			return i
+		}
+	}
+	return -1
+}
+
+// freeIndex returns the least index that isn't used by runners of the pool,
+// so indexes stay below the maximum size of the pool
+func (executor *Executor) freeIndex() int {
+	used := make(map[int]bool, len(executor.runners))
+	for _, runner := range executor.runners {
+		used[runner.index] = true
+	}
+
+	index := 0
+	for used[index] {
+		index++
+	}
+
+	return index
+}
+
+type idleTimer struct {
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (executor *Executor) newIdleTimer() *idleTimer {
+	if executor.maxRunners == executor.minRunners || executor.options.idleTimeout <= 0 {
+		return &idleTimer{}
+	}
+
+	timer := time.NewTimer(executor.options.idleTimeout)
+	timer.Stop()
+
+	return &idleTimer{
+		timer:   timer,
+		timeout: executor.options.idleTimeout,
+	}
+}
+
+// wait restarts the timer and returns its channel, nil if the runner can't
+// be removed for being idle
+func (idle *idleTimer) wait() <-chan time.Time {
+	if idle.timer == nil {
+		return nil
+	}
+
+	idle.timer.Reset(idle.timeout)
+
+	return idle.timer.C
+}
+
+func (idle *idleTimer) stop() {
+	if idle.timer != nil && !idle.timer.Stop() {
+		<-idle.timer.C
+	}
+}
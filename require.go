@@ -0,0 +1,134 @@
+package gojs
+
+import (
+	"errors"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+// requireShim is compiled by the first require call in a context. It takes
+// the helpers away from the global object and replaces the global require
+// with a JavaScript function, so the shim is compiled once per context. The
+// module cache is kept by the context, so cycles see partially filled
+// exports as in Node.js
+const requireShim = `
+const resolve = globalThis.__gojsResolveModule;
+const compile = globalThis.__gojsCompileModule;
+const current = globalThis.__gojsCurrentScript;
+delete globalThis.__gojsResolveModule;
+delete globalThis.__gojsCompileModule;
+delete globalThis.__gojsCurrentScript;
+const cache = new Map();
+const requireFrom = function (parent, specifier) {
+	const resolved = resolve(String(specifier), parent);
+	const id = resolved.id;
+	let module = cache.get(id);
+	if (module !== undefined) {
+		return module.exports;
+	}
+	module = { id: id, filename: id, exports: {}, loaded: false };
+	cache.set(id, module);
+	try {
+		if (id.endsWith('.json')) {
+			module.exports = JSON.parse(resolved.source);
+		} else {
+			const body = compile(id, resolved.source);
+			const pos = id.lastIndexOf('/');
+			const dirname = pos < 0 ? '.' : id.slice(0, pos);
+			const require = (s) => requireFrom(id, s);
+			body.call(module.exports, module.exports, require, module, id, dirname);
+		}
+	} catch (e) {
+		cache.delete(id);
+		throw e;
+	}
+	module.loaded = true;
+	return module.exports;
+};
+globalThis.require = function require(specifier) {
+	return requireFrom(current(), specifier);
+};
+return globalThis.require(specifier);
+`
+
+var wrapperParams = []string{"exports", "require", "module", "__filename", "__dirname"}
+
+// commonJS implements require for a runner. Like eventLoop it is used only
+// from the goroutine of the runner
+type commonJS struct {
+	runner *runnerCtx
+	loader engines.ModuleLoader
+}
+
+type resolvedModule struct {
+	ID     string `js:"id"`
+	Source string `js:"source"`
+}
+
+func installRequire(runner *runnerCtx, loader engines.ModuleLoader) error {
+	r := &commonJS{
+		runner: runner,
+		loader: loader,
+	}
+
+	funcs := []struct {
+		name string
+		fn   interface{}
+	}{
+		{"require", r.require},
+		{"__gojsResolveModule", r.resolve},
+		{"__gojsCompileModule", r.compile},
+		{"__gojsCurrentScript", r.current},
+	}
+
+	for _, f := range funcs {
+		err := runner.runner.RegisterFunc(f.name, f.fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// require is called only once per context, the shim replaces it
+func (r *commonJS) require(specifier engines.Value) (engines.Value, error) {
+	if specifier == nil {
+		return nil, errors.New("module specifier is expected")
+	}
+
+	defer specifier.Dispose()
+
+	shim, err := r.runner.runner.CompileFunction("gojs:require", requireShim, []string{"specifier"})
+	if err != nil {
+		return nil, err
+	}
+
+	defer shim.Dispose()
+
+	fn, err := shim.ToFunction()
+	if err != nil {
+		return nil, err
+	}
+
+	defer fn.Dispose()
+
+	return fn.Call(specifier)
+}
+
+func (r *commonJS) resolve(specifier, parent string) (resolvedModule, error) {
+	id, source, err := r.loader.Resolve(specifier, parent)
+	if err != nil {
+		return resolvedModule{}, err
+	}
+
+	return resolvedModule{ID: id, Source: source}, nil
+}
+
+func (r *commonJS) compile(id, source string) (engines.Value, error) {
+	return r.runner.runner.CompileFunction(id, source, wrapperParams)
+}
+
+func (r *commonJS) current() string {
+	return r.runner.current
+}
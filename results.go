@@ -0,0 +1,113 @@
+package gojs
+
+import (
+	"sync/atomic"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+// result is a value returned to a caller. It keeps the isolate of the runner
+// that made it, so the isolate isn't deleted while the value is in use even
+// if the runner is removed from the pool or replaced after a failure
+type result struct {
+	engines.Value
+	runner   *runnerCtx
+	disposed int32
+}
+
+func (res *result) Dispose() {
+	if !atomic.CompareAndSwapInt32(&res.disposed, 0, 1) {
+		return
+	}
+
+	res.Value.Dispose()
+	res.runner.untrack()
+}
+
+func (res *result) PromiseResult() (engines.Value, error) {
+	val, err := res.Value.PromiseResult()
+	return res.runner.track(val), err
+}
+
+func (res *result) ToFunction() (engines.Function, error) {
+	fn, err := res.Value.ToFunction()
+	if err != nil {
+		return nil, err
+	}
+
+	res.runner.retain()
+
+	return &resultFunction{
+		Function: fn,
+		runner:   res.runner,
+	}, nil
+}
+
+// resultFunction is a function got from a result, it keeps the isolate like
+// the result itself
+type resultFunction struct {
+	engines.Function
+	runner   *runnerCtx
+	disposed int32
+}
+
+func (fn *resultFunction) Call(args ...engines.Value) (engines.Value, error) {
+	values := make([]engines.Value, len(args))
+	for i, arg := range args {
+		values[i] = unwrap(arg)
+	}
+
+	val, err := fn.Function.Call(values...)
+	return fn.runner.track(val), err
+}
+
+func (fn *resultFunction) Dispose() {
+	if !atomic.CompareAndSwapInt32(&fn.disposed, 0, 1) {
+		return
+	}
+
+	fn.Function.Dispose()
+	fn.runner.untrack()
+}
+
+// unwrap returns the value of the engine, results may be passed back as
+// arguments
+func unwrap(val engines.Value) engines.Value {
+	if res, ok := val.(*result); ok {
+		return res.Value
+	}
+	return val
+}
+
+// track wraps a value made by the runner before it's returned to a caller
+func (ctx *runnerCtx) track(val engines.Value) engines.Value {
+	if val == nil {
+		return nil
+	}
+
+	ctx.retain()
+
+	return &result{
+		Value:  val,
+		runner: ctx,
+	}
+}
+
+func (ctx *runnerCtx) retain() {
+	ctx.mutex.Lock()
+	ctx.values++
+	ctx.mutex.Unlock()
+}
+
+// untrack deletes the isolate of a disposed runner when its last value is
+// disposed
+func (ctx *runnerCtx) untrack() {
+	ctx.mutex.Lock()
+	ctx.values--
+	last := ctx.values == 0 && ctx.disposed
+	ctx.mutex.Unlock()
+
+	if last {
+		ctx.runner.Dispose()
+	}
+}
@@ -0,0 +1,234 @@
+package gojs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+// ErrSessionClosed is returned by tasks of a closed session
+var ErrSessionClosed = errors.New("gojs.Session: session is closed")
+
+// Session runs scripts on one runner in a context of its own, so the globals
+// set by a task are seen by the next tasks of the session and by no one
+// else. A script is compiled in the session the first time it is used, later
+// changes made by Replace don't affect the session
+type Session struct {
+	executor *Executor
+	runner   *runnerCtx
+	tasks    taskChannel
+	pinned   *int32
+
+	mutex  sync.Mutex
+	closed bool
+
+	// the fields below are used only by the goroutine of the runner
+	context  engines.Context
+	scripts  map[string]*scriptCtx
+	disposed bool
+}
+
+// NewSession creates a session pinned to one of the runners, sessions are
+// distributed over the runners in turn. The session must be closed by Close
+func (executor *Executor) NewSession() (*Session, error) {
+	n := atomic.AddUint32(&executor.nextSession, 1)
+
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+
+	if executor.closed {
+		return nil, ErrClosed
+	}
+
+	if len(executor.runners) == 0 {
+		return nil, errors.New("gojs.Executor.NewSession: all runners have failed")
+	}
+
+	runner := executor.runners[int(n-1)%len(executor.runners)]
+
+	// a runner with sessions is never removed from the pool
+	atomic.AddInt32(runner.pinned, 1)
+
+	return &Session{
+		executor: executor,
+		runner:   runner,
+		tasks:    runner.ownTasks,
+		pinned:   runner.pinned,
+		scripts:  make(map[string]*scriptCtx),
+	}, nil
+}
+
+func (session *Session) submit(ctx context.Context, t *task) (ResultChannel, error) {
+	session.mutex.Lock()
+	closed := session.closed
+	session.mutex.Unlock()
+
+	if closed {
+		return nil, ErrSessionClosed
+	}
+
+	t.session = session
+
+	return session.executor.submit(ctx, t)
+}
+
+func (session *Session) Run(scriptName string) (engines.Value, error) {
+	return session.RunContext(context.Background(), scriptName)
+}
+
+// RunContext runs the script like Executor.RunContext in the context of
+// the session
+func (session *Session) RunContext(ctx context.Context, scriptName string) (engines.Value, error) {
+	if len(scriptName) == 0 {
+		return nil, errors.New("gojs.Session.Run: you must specify scriptID")
+	}
+
+	return wait(session.submit(ctx, &task{
+		cmd:  run,
+		name: scriptName,
+	}))
+}
+
+func (session *Session) Call(scriptName, funcName string, args ...interface{}) (engines.Value, error) {
+	return session.CallContext(context.Background(), scriptName, funcName, args...)
+}
+
+// CallContext calls the function like Executor.CallContext in the context
+// of the session
+func (session *Session) CallContext(ctx context.Context, scriptName, funcName string, args ...interface{}) (engines.Value, error) {
+	if len(scriptName) == 0 {
+		return nil, errors.New("gojs.Session.Call: you must specify scriptID")
+	}
+
+	if len(funcName) == 0 {
+		return nil, errors.New("gojs.Session.Call: you must specify function name")
+	}
+
+	return wait(session.submit(ctx, &task{
+		cmd:      callFunction,
+		name:     scriptName,
+		function: funcName,
+		args:     args,
+	}))
+}
+
+// Close waits for the tasks of the session that are already running and
+// disposes the context of the session
+func (session *Session) Close() error {
+	return session.CloseContext(context.Background())
+}
+
+// CloseContext closes the session like Close, but stops waiting for the runner
+// when ctx is done, e.g. if the runner has failed and can't be respawned. The
+// context of the session is disposed together with the runner then
+func (session *Session) CloseContext(ctx context.Context) error {
+	session.mutex.Lock()
+	closed := session.closed
+	session.closed = true
+	session.mutex.Unlock()
+
+	if closed {
+		return ErrSessionClosed
+	}
+
+	// the runner is unpinned on every path, so it can be removed from
+	// the pool and a failed one isn't respawned for this session anymore
+	defer atomic.AddInt32(session.pinned, -1)
+
+	// the context has been disposed with the runner if it's been replaced
+	session.executor.mutex.Lock()
+	replaced := session.executor.position(session.runner) < 0
+	session.executor.mutex.Unlock()
+
+	if replaced {
+		return nil
+	}
+
+	done, err := session.executor.submit(ctx, &task{
+		cmd:     closeSession,
+		session: session,
+	})
+	if err != nil {
+		return err
+	}
+
+	<-done
+
+	return nil
+}
+
+func (session *Session) script(runner *runnerCtx, scriptName string) (*scriptCtx, error) {
+	if session.disposed {
+		return nil, ErrSessionClosed
+	}
+
+	if session.runner != runner {
+		return nil, errors.New("gojs.Session: the runner of the session has been replaced, its state is lost")
+	}
+
+	script := session.scripts[scriptName]
+	if script != nil {
+		return script, nil
+	}
+
+	session.executor.mutex.Lock()
+	src, ok := session.executor.sources[scriptName]
+	session.executor.mutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("gojs.Executor: can't find script '%s'", scriptName)
+	}
+
+	if src.module {
+		return nil, fmt.Errorf("gojs.Session: '%s' is a module, modules can't be used in sessions", scriptName)
+	}
+
+	if session.context == nil {
+		context, err := runner.runner.NewContext()
+		if err != nil {
+			return nil, err
+		}
+
+		session.context = context
+		runner.sessions[session] = struct{}{}
+	}
+
+	script, err := newScriptCtx(session.context.Compile(scriptName, src.code))
+	if err != nil {
+		return nil, err
+	}
+
+	session.scripts[scriptName] = script
+
+	return script, nil
+}
+
+// release disposes the scripts and the context of the session, it's called
+// when the session is closed or when its runner is disposed
+func (session *Session) release(runner *runnerCtx) {
+	if session.context == nil {
+		return
+	}
+
+	for _, script := range session.scripts {
+		script.dispose()
+	}
+	session.scripts = nil
+
+	session.context.Dispose()
+	session.context = nil
+
+	delete(runner.sessions, session)
+}
+
+func (session *Session) dispose(runner *runnerCtx) {
+	if session.runner == runner {
+		session.release(runner)
+	}
+
+	session.disposed = true
+}
@@ -0,0 +1,155 @@
+package gojs
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClosed is returned by methods of an Executor after Shutdown or Dispose
+var ErrClosed = errors.New("gojs.Executor: executor is closed")
+
+func (executor *Executor) start(runner *runnerCtx) {
+	executor.running.Add(1)
+	go runner.start()
+}
+
+// finish sends the result of an accepted task, result is nil for tasks that
+// have no result
+func (executor *Executor) finish(t *task, result *Result) {
+	if result != nil {
+		t.res <- result
+	}
+	close(t.res)
+	executor.tasks.Done()
+}
+
+func (executor *Executor) aborted() bool {
+	select {
+	case <-executor.abort:
+		return true
+	default:
+		return false
+	}
+}
+
+// drain fails the tasks waiting in the queues with ErrClosed, so they don't
+// wait for runners that may be gone or stuck. Queues of sessions aren't
+// buffered, senders blocked on them give up when the executor is done
+func (executor *Executor) drain() {
+	executor.mutex.Lock()
+	queues := []taskChannel{executor.pendingTasks}
+	for _, runner := range executor.runners {
+		queues = append(queues, runner.ownTasks)
+	}
+	executor.mutex.Unlock()
+
+	for _, queue := range queues {
+		executor.drainQueue(queue)
+	}
+}
+
+func (executor *Executor) drainQueue(queue taskChannel) {
+	for {
+		select {
+		case t := <-queue:
+			if t == nil {
+				continue
+			}
+			if t.cmd == closeSession {
+				// the context of the session is released with its runner
+				executor.finish(t, nil)
+			} else {
+				executor.finish(t, &Result{Err: ErrClosed})
+			}
+		default:
+			return
+		}
+	}
+}
+
+// stop disposes the runners once all of them have exited
+func (executor *Executor) stop() {
+	executor.running.Wait()
+
+	executor.mutex.Lock()
+	for _, runner := range executor.runners {
+		runner.dispose()
+	}
+	executor.runners = nil
+	executor.mutex.Unlock()
+
+	executor.engine.Dispose()
+}
+
+// Shutdown stops accepting tasks and waits until the accepted ones are
+// finished. If ctx is done first, running tasks are terminated, queued ones
+// fail with ErrClosed and Shutdown returns ctx.Err() without waiting for the
+// running ones, e.g. a Go function that doesn't return. Then the runners are
+// stopped and disposed, in the background if Shutdown hasn't waited for them.
+// All methods return ErrClosed afterwards
+func (executor *Executor) Shutdown(ctx context.Context) error {
+	return executor.shutdown(ctx, false)
+}
+
+// shutdown implements Shutdown, with wait the runners are stopped and
+// disposed before it returns even if ctx is done
+func (executor *Executor) shutdown(ctx context.Context, wait bool) error {
+	executor.mutex.Lock()
+	executor.lifecycle.Lock()
+	closed := executor.closed
+	executor.closed = true
+	executor.lifecycle.Unlock()
+	executor.mutex.Unlock()
+
+	if closed {
+		return ErrClosed
+	}
+
+	close(executor.done)
+
+	drained := make(chan struct{})
+	go func() {
+		executor.tasks.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		close(executor.abort)
+
+		executor.mutex.Lock()
+		for _, runner := range executor.runners {
+			runner.runner.Terminate()
+		}
+		executor.mutex.Unlock()
+
+		executor.drain()
+
+		close(executor.quit)
+		if wait {
+			executor.stop()
+		} else {
+			go executor.stop()
+		}
+
+		return ctx.Err()
+	}
+
+	close(executor.quit)
+	executor.stop()
+
+	return nil
+}
+
+// Dispose terminates running tasks and frees all resources, it's Shutdown
+// that doesn't wait for the tasks, but returns only when the runners are
+// disposed. A Go function called by a script can't be terminated, so Dispose
+// blocks until such a function returns. It's safe to call Dispose more than
+// once
+func (executor *Executor) Dispose() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	executor.shutdown(ctx, true)
+}
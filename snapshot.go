@@ -0,0 +1,31 @@
+package gojs
+
+import (
+	"errors"
+
+	"github.com/mtrempoltsev/gojs/engines"
+	"github.com/mtrempoltsev/gojs/engines/v8"
+)
+
+// SnapshotScript is a bootstrap script for BuildSnapshot
+type SnapshotScript = engines.SnapshotScript
+
+// BuildSnapshot executes scripts one by one in a fresh context and returns
+// the serialized heap. Pass it to New with WithSnapshot, so runners start
+// with the globals defined by the scripts instead of compiling and running
+// them again. Host functions such as console or setTimeout are not
+// available while the snapshot is built. It's safe to call while executors
+// are running, they share the V8 instance with it
+func BuildSnapshot(scripts ...SnapshotScript) ([]byte, error) {
+	if len(scripts) == 0 {
+		return nil, errors.New("gojs.BuildSnapshot: no scripts, nothing to snapshot")
+	}
+
+	engine, err := v8.New(1)
+	if err != nil {
+		return nil, err
+	}
+	defer engine.Dispose()
+
+	return engine.BuildSnapshot(scripts)
+}
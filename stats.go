@@ -0,0 +1,146 @@
+package gojs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mtrempoltsev/gojs/engines"
+)
+
+// DefaultBuckets are the upper bounds in seconds of histogram buckets
+var DefaultBuckets = []float64{
+	0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025,
+	0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// Histogram counts observations in buckets, Counts[i] is the number of
+// observations less than or equal to Bounds[i] and greater than the previous
+// bound, the last element of Counts is for values above all bounds
+type Histogram struct {
+	Bounds []float64
+	Counts []uint64
+	Count  uint64
+	Sum    float64
+}
+
+func newHistogram(bounds []float64) Histogram {
+	return Histogram{
+		Bounds: bounds,
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+func (h *Histogram) observe(value float64) {
+	i := 0
+	for i < len(h.Bounds) && value > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += value
+}
+
+func (h Histogram) clone() Histogram {
+	res := h
+	res.Counts = append([]uint64(nil), h.Counts...)
+	return res
+}
+
+type RunnerStats struct {
+	Runner        int
+	Heap          engines.HeapStatistics
+	Scripts       int
+	TasksExecuted uint64
+	// QueueWait is the time in seconds tasks spent waiting for the runner,
+	// ExecutionTime is the time in seconds it took to execute them
+	QueueWait     Histogram
+	ExecutionTime Histogram
+}
+
+type runnerStats struct {
+	heap          engines.HeapStatistics
+	tasksExecuted uint64
+	queueWait     Histogram
+	executionTime Histogram
+	mutex         sync.Mutex
+}
+
+func newRunnerStats() *runnerStats {
+	return &runnerStats{
+		queueWait:     newHistogram(DefaultBuckets),
+		executionTime: newHistogram(DefaultBuckets),
+	}
+}
+
+// update is called by the runner after each task. Heap statistics are taken
+// here rather than in Stats, so Stats never waits for a busy runner
+func (stats *runnerStats) update(runner engines.Runner, queueWait, executionTime time.Duration) {
+	heap := runner.HeapStatistics()
+
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+
+	stats.heap = heap
+	stats.tasksExecuted++
+	stats.queueWait.observe(queueWait.Seconds())
+	stats.executionTime.observe(executionTime.Seconds())
+}
+
+func (ctx *runnerCtx) stats() RunnerStats {
+	ctx.mutex.RLock()
+	scripts := len(ctx.scripts)
+	ctx.mutex.RUnlock()
+
+	ctx.statistics.mutex.Lock()
+	defer ctx.statistics.mutex.Unlock()
+
+	return RunnerStats{
+		Runner:        ctx.index,
+		Heap:          ctx.statistics.heap,
+		Scripts:       scripts,
+		TasksExecuted: ctx.statistics.tasksExecuted,
+		QueueWait:     ctx.statistics.queueWait.clone(),
+		ExecutionTime: ctx.statistics.executionTime.clone(),
+	}
+}
+
+// Stats returns statistics of every runner. Heap statistics are sampled after
+// each task, so they may be slightly outdated for a busy runner
+func (executor *Executor) Stats() []RunnerStats {
+	executor.mutex.Lock()
+	runners := append([]*runnerCtx(nil), executor.runners...)
+	executor.mutex.Unlock()
+
+	res := make([]RunnerStats, len(runners))
+	for i, runner := range runners {
+		res[i] = runner.stats()
+	}
+
+	return res
+}
+
+type queueCounters struct {
+	rejectedFull    uint64
+	rejectedExpired uint64
+}
+
+// QueueStats describes the queue of tasks waiting for a free runner
+type QueueStats struct {
+	Length   int
+	Capacity int
+	// RejectedFull is the number of tasks rejected with ErrQueueFull
+	RejectedFull uint64
+	// RejectedExpired is the number of tasks whose context was done before
+	// a runner started them
+	RejectedExpired uint64
+}
+
+func (executor *Executor) QueueStats() QueueStats {
+	return QueueStats{
+		Length:          len(executor.pendingTasks),
+		Capacity:        cap(executor.pendingTasks),
+		RejectedFull:    atomic.LoadUint64(&executor.queue.rejectedFull),
+		RejectedExpired: atomic.LoadUint64(&executor.queue.rejectedExpired),
+	}
+}
@@ -0,0 +1,193 @@
+package gojs
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRunnerDead is returned when the isolate of a runner has died, the runner
+// is replaced with a new one
+var ErrRunnerDead = errors.New("gojs.Executor: runner is dead")
+
+const (
+	minRespawnDelay = 100 * time.Millisecond
+	maxRespawnDelay = 10 * time.Second
+)
+
+type RunnerEventKind int
+
+const (
+	// RunnerFailed is sent when a runner has reached the heap limit, its
+	// isolate has died or its goroutine has panicked
+	RunnerFailed RunnerEventKind = iota
+	// RunnerRespawned is sent when a failed runner has been replaced and
+	// all scripts have been compiled in the new one
+	RunnerRespawned
+	// RunnerRespawnFailed is sent when a failed runner can't be replaced,
+	// it's removed from the pool and the replacement is retried later
+	RunnerRespawnFailed
+)
+
+func (kind RunnerEventKind) String() string {
+	switch kind {
+	case RunnerFailed:
+		return "failed"
+	case RunnerRespawned:
+		return "respawned"
+	case RunnerRespawnFailed:
+		return "respawn failed"
+	}
+	return "unknown"
+}
+
+type RunnerEvent struct {
+	Kind   RunnerEventKind
+	Runner int
+	// Err is the reason of the failure for RunnerFailed and the error of
+	// the new runner for RunnerRespawnFailed
+	Err  error
+	Time time.Time
+}
+
+type healthCounters struct {
+	failures uint64
+	respawns uint64
+}
+
+// Health describes failures of runners
+type Health struct {
+	// Runners is the number of runners in the pool, failed runners that
+	// haven't been respawned yet aren't counted
+	Runners  int
+	Failures uint64
+	Respawns uint64
+}
+
+func (executor *Executor) Health() Health {
+	executor.mutex.Lock()
+	runners := len(executor.runners)
+	executor.mutex.Unlock()
+
+	return Health{
+		Runners:  runners,
+		Failures: atomic.LoadUint64(&executor.health.failures),
+		Respawns: atomic.LoadUint64(&executor.health.respawns),
+	}
+}
+
+func (executor *Executor) emit(kind RunnerEventKind, runner int, err error) {
+	if executor.options.runnerEvents == nil {
+		return
+	}
+
+	executor.options.runnerEvents(RunnerEvent{
+		Kind:   kind,
+		Runner: runner,
+		Err:    err,
+		Time:   time.Now(),
+	})
+}
+
+// respawn replaces a failed runner with a new one that has all registered
+// functions and scripts. If the new runner can't be created, the failed one
+// is removed from the pool and respawning is retried in the background. It's
+// called from the goroutine of the failed runner, which must exit if respawn
+// returns true
+func (executor *Executor) respawn(old *runnerCtx, reason error) bool {
+	atomic.AddUint64(&executor.health.failures, 1)
+	executor.emit(RunnerFailed, old.index, reason)
+
+	executor.mutex.Lock()
+
+	if executor.closed {
+		executor.mutex.Unlock()
+		return false
+	}
+
+	i := executor.position(old)
+	if i < 0 {
+		executor.mutex.Unlock()
+		return false
+	}
+
+	runner, err := executor.newRunner(old.index)
+	if err != nil {
+		executor.runners = append(executor.runners[:i], executor.runners[i+1:]...)
+		old.dispose()
+		executor.mutex.Unlock()
+
+		executor.emit(RunnerRespawnFailed, old.index, err)
+
+		go executor.retry(old)
+
+		return true
+	}
+
+	runner.ownTasks = old.ownTasks
+	runner.pinned = old.pinned
+
+	executor.runners[i] = runner
+	old.dispose()
+
+	executor.start(runner)
+
+	executor.mutex.Unlock()
+
+	atomic.AddUint64(&executor.health.respawns, 1)
+	executor.emit(RunnerRespawned, runner.index, nil)
+
+	return true
+}
+
+// retry tries to create a runner to replace the failed one with a growing
+// delay until it succeeds or the executor is closed
+func (executor *Executor) retry(old *runnerCtx) {
+	delay := minRespawnDelay
+
+	for {
+		select {
+		case <-time.After(delay):
+		case <-executor.done:
+			return
+		}
+
+		executor.mutex.Lock()
+
+		if executor.closed {
+			executor.mutex.Unlock()
+			return
+		}
+
+		// the pool has grown back, unless sessions still wait for
+		// the failed runner
+		if len(executor.runners) >= executor.maxRunners && atomic.LoadInt32(old.pinned) == 0 {
+			executor.mutex.Unlock()
+			return
+		}
+
+		runner, err := executor.newRunner(executor.freeIndex())
+		if err == nil {
+			runner.ownTasks = old.ownTasks
+			runner.pinned = old.pinned
+
+			executor.runners = append(executor.runners, runner)
+			executor.start(runner)
+		}
+
+		executor.mutex.Unlock()
+
+		if err == nil {
+			atomic.AddUint64(&executor.health.respawns, 1)
+			executor.emit(RunnerRespawned, runner.index, nil)
+			return
+		}
+
+		executor.emit(RunnerRespawnFailed, old.index, err)
+
+		delay *= 2
+		if delay > maxRespawnDelay {
+			delay = maxRespawnDelay
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCall(t *testing.T) {
+	err := _jsExecutor.Compile("call.js", "function add(a, b) { return a + b; }")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	for i := 0; i < 10; i++ {
+		res, err := _jsExecutor.Call("call.js", "add", i, 2)
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		val, err := res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i+2), val)
+	}
+
+	res, err := _jsExecutor.Call("call.js", "add", "a", "b")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err := res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", str)
+}
+
+func TestCallErrors(t *testing.T) {
+	_, err := _jsExecutor.Call("unknown.js", "add")
+
+	assert.Error(t, err)
+	assert.Equal(t, "gojs.Executor: can't find script 'unknown.js'", err.Error())
+
+	err = _jsExecutor.Compile("call_errors.js", "function fail() { throw new Error('boom'); }")
+
+	assert.NoError(t, err)
+
+	_, err = _jsExecutor.Call("call_errors.js", "fail")
+
+	assert.Error(t, err)
+
+	_, err = _jsExecutor.Call("call_errors.js", "unknown")
+
+	assert.Error(t, err)
+
+	_, err = _jsExecutor.Call("call_errors.js", "fail", make(chan int))
+
+	assert.Error(t, err)
+}
+
+func TestCallWithObjects(t *testing.T) {
+	err := _jsExecutor.Compile("call_objects.js",
+		"function describe(u) {"+
+			"  return [u.name, u.age, u.tags.join(','), u.address.city,"+
+			"          u.scores.b, 'hidden' in u, 'note' in u].join(':');"+
+			"}")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	type address struct {
+		City string `json:"city"`
+	}
+
+	type user struct {
+		Name    string         `json:"name"`
+		Age     int            `json:"age"`
+		Tags    []string       `json:"tags"`
+		Address *address       `json:"address"`
+		Scores  map[string]int `json:"scores"`
+		Hidden  bool           `json:"-"`
+		Note    string         `json:"note,omitempty"`
+	}
+
+	res, err := _jsExecutor.Call("call_objects.js", "describe", user{
+		Name:    "bob",
+		Age:     42,
+		Tags:    []string{"a", "b"},
+		Address: &address{City: "Paris"},
+		Scores:  map[string]int{"a": 1, "b": 2},
+		Hidden:  true,
+	})
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err := res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "bob:42:a,b:Paris:2:false:false", str)
+}
+
+func TestCallWithEmbeddedPointers(t *testing.T) {
+	err := _jsExecutor.Compile("call_embedded.js",
+		"function keys(obj) { return Object.keys(obj).sort().join(','); }"+
+			"function echo(obj) { return obj; }")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	type Base struct {
+		ID int `json:"id"`
+	}
+
+	type Extra struct {
+		Note string `json:"note"`
+	}
+
+	type item struct {
+		*Base
+		*Extra
+		Name string `json:"name"`
+	}
+
+	obj := item{Base: &Base{ID: 7}, Name: "x"}
+
+	res, err := _jsExecutor.Call("call_embedded.js", "keys", obj)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err := res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id,name", str)
+
+	res, err = _jsExecutor.Call("call_embedded.js", "echo", obj)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	var back item
+
+	assert.NoError(t, res.ToObject(&back))
+	assert.Equal(t, obj.Base, back.Base)
+	assert.Equal(t, "x", back.Name)
+	assert.Nil(t, back.Extra)
+}
+
+func TestCallSkipsUnexportedFields(t *testing.T) {
+	err := _jsExecutor.Compile("call_unexported.js",
+		"function keys(obj) { return Object.keys(obj).sort().join(','); }")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	type item struct {
+		sync.Mutex
+		Name string `json:"name"`
+		done chan struct{}
+		hook func()
+	}
+
+	res, err := _jsExecutor.Call("call_unexported.js", "keys", &item{Name: "x"})
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err := res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "name", str)
+}
+
+func TestCallCycle(t *testing.T) {
+	err := _jsExecutor.Compile("call_cycle.js", "function echo(obj) { return obj; }")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	type node struct {
+		Next *node `json:"next"`
+	}
+
+	n := &node{}
+	n.Next = n
+
+	_, err = _jsExecutor.Call("call_cycle.js", "echo", n)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	err := _jsExecutor.RegisterFunc("fetchUser", func(id int64) (user, error) {
+		if id != 1 {
+			return user{}, errors.New("user not found")
+		}
+		return user{Name: "bob", Age: 42}, nil
+	})
+
+	assert.NoError(t, err)
+
+	res, err := runScript("callbacks.js", "let u = fetchUser(1); u.name + ':' + u.age")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err := res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "bob:42", str)
+
+	res, err = runScript("callbacks.js",
+		"let msg; try { fetchUser(2) } catch (e) { msg = e.message }; msg")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err = res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user not found", str)
+}
+
+func TestRegisterFuncErrors(t *testing.T) {
+	err := _jsExecutor.RegisterFunc("notFunc", 42)
+
+	assert.Error(t, err)
+
+	err = _jsExecutor.RegisterFunc("tooManyResults", func() (int, int, error) {
+		return 0, 0, nil
+	})
+
+	assert.Error(t, err)
+
+	err = _jsExecutor.RegisterFunc("half", func(x int8) int8 {
+		return x / 2
+	})
+
+	assert.NoError(t, err)
+
+	_, err = runScript("callbacks.js", "half(1000)")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeCache(t *testing.T) {
+	const code = "function mul(a, b) { return a * b; } mul(6, 7)"
+
+	err := _jsExecutor.Compile("cache.js", code)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	cache, err := _jsExecutor.CodeCache("cache.js")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, cache)
+
+	accepted, err := _jsExecutor.CompileWithCache("cache_copy.js", code, cache)
+
+	assert.NoError(t, err)
+	assert.True(t, accepted)
+
+	res, err := _jsExecutor.Run("cache_copy.js")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	val, err := res.ToInt()
+	res.Dispose()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), val)
+
+	// the cache doesn't match the code, so it's compiled from scratch
+	accepted, err = _jsExecutor.CompileWithCache("cache_other.js", "mul(6, 7) + 1; function mul(a, b) { return a * b; }", cache)
+
+	assert.NoError(t, err)
+	assert.False(t, accepted)
+
+	res, err = _jsExecutor.Run("cache_other.js")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	val, err = res.ToInt()
+	res.Dispose()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(43), val)
+
+	accepted, err = _jsExecutor.CompileWithCache("cache_garbage.js", code, []byte("garbage"))
+
+	assert.NoError(t, err)
+	assert.False(t, accepted)
+
+	_, err = _jsExecutor.CodeCache("missing.js")
+	assert.Error(t, err)
+
+	err = _jsExecutor.CompileModule("cache_module.js", "export const x = 1;")
+
+	assert.NoError(t, err)
+
+	_, err = _jsExecutor.CodeCache("cache_module.js")
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsole(t *testing.T) {
+	var mutex sync.Mutex
+	var messages []gojs.ConsoleMessage
+
+	js, err := gojs.New(1, gojs.WithConsole(func(msg gojs.ConsoleMessage) {
+		mutex.Lock()
+		messages = append(messages, msg)
+		mutex.Unlock()
+	}))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	err = js.Compile("console.js",
+		"console.log('hello', 42, [1, 'a'], {x: true});"+
+			"console.warn('careful');"+
+			"console.assert(1 == 1, 'not printed');"+
+			"console.assert(false, 'printed');"+
+			"console.time('t'); console.timeEnd('t');")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	res, err := js.Run("console.js")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	res.Dispose()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !assert.Len(t, messages, 4) {
+		return
+	}
+
+	assert.Equal(t, gojs.ConsoleMessage{
+		Level:   gojs.ConsoleLog,
+		Message: `hello 42 [1, "a"] { x: true }`,
+		Script:  "console.js",
+		Runner:  0,
+	}, messages[0])
+
+	assert.Equal(t, gojs.ConsoleWarn, messages[1].Level)
+	assert.Equal(t, "careful", messages[1].Message)
+
+	assert.Equal(t, gojs.ConsoleError, messages[2].Level)
+	assert.Equal(t, "Assertion failed: printed", messages[2].Message)
+
+	assert.Equal(t, gojs.ConsoleLog, messages[3].Level)
+	assert.Regexp(t, `^t: \d+\.\d{3}ms$`, messages[3].Message)
+}
+
+func TestConsoleTrace(t *testing.T) {
+	var messages []gojs.ConsoleMessage
+
+	js, err := gojs.New(1, gojs.WithConsole(func(msg gojs.ConsoleMessage) {
+		messages = append(messages, msg)
+	}))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	err = js.Compile("trace.js", "function f() {\n  console.trace('here');\n}\nf();")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	res, err := js.Run("trace.js")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	res.Dispose()
+
+	if !assert.Len(t, messages, 1) {
+		return
+	}
+
+	assert.Equal(t, gojs.ConsoleTrace, messages[0].Level)
+	assert.Regexp(t, `^Trace: here\n\s+at f \(trace\.js:2:\d+\)\n\s+at trace\.js:4:\d+`, messages[0].Message)
+	assert.NotContains(t, messages[0].Message, "gojs:console")
+}
+
+func TestConsoleTable(t *testing.T) {
+	var messages []gojs.ConsoleMessage
+
+	js, err := gojs.New(1, gojs.WithConsole(func(msg gojs.ConsoleMessage) {
+		messages = append(messages, msg)
+	}))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	err = js.Compile("table.js",
+		"console.table([{a: 1, b: 'Y'}, {a: 'Z', c: [2]}, 3]);"+
+			"console.table({x: {a: 1, b: 2}}, ['b']);"+
+			"console.table(42);")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	res, err := js.Run("table.js")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	res.Dispose()
+
+	if !assert.Len(t, messages, 3) {
+		return
+	}
+
+	for _, msg := range messages {
+		assert.Equal(t, gojs.ConsoleLog, msg.Level)
+	}
+
+	assert.Equal(t, ""+
+		"┌─────────┬─────┬─────┬─────┬────────┐\n"+
+		"│ (index) │ a   │ b   │ c   │ Values │\n"+
+		"├─────────┼─────┼─────┼─────┼────────┤\n"+
+		"│ 0       │ 1   │ \"Y\" │     │        │\n"+
+		"│ 1       │ \"Z\" │     │ [2] │        │\n"+
+		"│ 2       │     │     │     │ 3      │\n"+
+		"└─────────┴─────┴─────┴─────┴────────┘", messages[0].Message)
+
+	assert.Equal(t, ""+
+		"┌─────────┬───┐\n"+
+		"│ (index) │ b │\n"+
+		"├─────────┼───┤\n"+
+		"│ x       │ 2 │\n"+
+		"└─────────┴───┘", messages[1].Message)
+
+	assert.Equal(t, "42", messages[2].Message)
+}
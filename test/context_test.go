@@ -0,0 +1,69 @@
+package test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunContextDeadline(t *testing.T) {
+	err := _jsExecutor.Compile("loop.js", "while (true) {}")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = _jsExecutor.RunContext(ctx, "loop.js")
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// all runners must still be usable
+	for i := 0; i < runtime.NumCPU()*2; i++ {
+		res, err := runScript("my.js", "2 + 2")
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		val, err := res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), val)
+	}
+}
+
+func TestCallContextCanceled(t *testing.T) {
+	err := _jsExecutor.Compile("loop_func.js", "function loop() { for (;;) {} }")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err = _jsExecutor.CallContext(ctx, "loop_func.js", "loop")
+
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = _jsExecutor.CallContext(ctx, "loop_func.js", "loop")
+
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,232 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mtrempoltsev/gojs/engines"
+	"github.com/stretchr/testify/assert"
+)
+
+type Address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type Audit struct {
+	CreatedBy string `js:"createdBy"`
+}
+
+type Customer struct {
+	Audit
+	*Address
+
+	ID      int64             `js:"id" json:"customer_id"`
+	Name    string            `json:"name"`
+	Age     uint8             `json:"age"`
+	Score   float32           `json:"score"`
+	Tags    []string          `json:"tags"`
+	Orders  []Order           `json:"orders"`
+	Limits  map[string]int    `json:"limits"`
+	ByID    map[int]string    `json:"byId"`
+	Point   [2]int            `json:"point"`
+	Note    *string           `json:"note"`
+	Extra   interface{}       `json:"extra"`
+	Raw     engines.Value     `json:"raw"`
+	Parent  *Customer         `json:"parent,omitempty"`
+	Ignored string            `json:"-"`
+	Meta    map[string]*Order `json:"meta"`
+}
+
+type Order struct {
+	Total int32 `json:"total"`
+}
+
+func TestDecode(t *testing.T) {
+	res, err := runScript("decode.js", `({
+		createdBy: 'admin',
+		city: 'Berlin',
+		id: 7,
+		name: 'Ann',
+		age: 42,
+		score: 0.5,
+		tags: ['a', 'b'],
+		orders: [{ total: 10 }, { total: 20 }],
+		limits: { day: 1, week: 7 },
+		byId: { '1': 'one', '2': 'two' },
+		point: [3, 4],
+		note: 'hi',
+		extra: { list: [1, 2.5, 'x', null, true] },
+		raw: [1, 2, 3],
+		parent: null,
+		meta: { first: { total: 1 }, none: null },
+	})`)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	var customer Customer
+
+	err = res.ToObject(&customer)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer customer.Raw.Dispose()
+
+	assert.Equal(t, "admin", customer.CreatedBy)
+	assert.Equal(t, "Berlin", customer.City)
+	assert.Equal(t, int64(7), customer.ID)
+	assert.Equal(t, "Ann", customer.Name)
+	assert.Equal(t, uint8(42), customer.Age)
+	assert.Equal(t, float32(0.5), customer.Score)
+	assert.Equal(t, []string{"a", "b"}, customer.Tags)
+	assert.Equal(t, []Order{{10}, {20}}, customer.Orders)
+	assert.Equal(t, map[string]int{"day": 1, "week": 7}, customer.Limits)
+	assert.Equal(t, map[int]string{1: "one", 2: "two"}, customer.ByID)
+	assert.Equal(t, [2]int{3, 4}, customer.Point)
+	assert.Equal(t, "hi", *customer.Note)
+	assert.Equal(t, map[string]interface{}{
+		"list": []interface{}{int64(1), 2.5, "x", nil, true},
+	}, customer.Extra)
+	assert.True(t, customer.Raw.IsArray())
+	assert.Nil(t, customer.Parent)
+	assert.Equal(t, map[string]*Order{"first": {1}, "none": nil}, customer.Meta)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	type small struct {
+		I8  int8    `json:"i8"`
+		U16 uint16  `json:"u16"`
+		F32 float32 `json:"f32"`
+	}
+
+	type order struct {
+		Total int32 `json:"total"`
+	}
+
+	type withOrders struct {
+		Orders []order `json:"orders"`
+	}
+
+	cases := []struct {
+		code string
+		obj  interface{}
+		err  string
+	}{
+		{"({ i8: 128 })", &small{}, "At small.i8: Value 128 overflows int8"},
+		{"({ u16: -1 })", &small{}, "At small.u16: Can't cast negative value -1 to unsigned value"},
+		{"({ u16: 65536 })", &small{}, "At small.u16: Value 65536 overflows uint16"},
+		{"({ f32: 1e300 })", &small{}, "At small.f32: Value 1e+300 overflows float32"},
+		{"({ unknown: 1 })", &small{}, "Type \"small\" has no field \"unknown\""},
+		{"({ orders: [{ total: 1 }, { total: 'x' }] })", &withOrders{}, "At withOrders.orders[1].total: Can't convert string to int64"},
+		{"1", &small{}, "Can't convert \"number\" to \"small\""},
+	}
+
+	for _, c := range cases {
+		res, err := runScript("decode.js", c.code)
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		err = res.ToObject(c.obj)
+		res.Dispose()
+
+		if assert.Error(t, err, c.code) {
+			assert.Equal(t, c.err, err.Error())
+		}
+	}
+
+	res, err := runScript("decode.js", "({ i8: 1, unknown: 2 })")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	var obj small
+
+	assert.NoError(t, res.ToObject(&obj, engines.IgnoreUnknownKeys()))
+	assert.Equal(t, int8(1), obj.I8)
+
+	assert.Error(t, res.ToObject(obj))
+}
+
+func TestDecodeMissingKeys(t *testing.T) {
+	res, err := runScript("decode_missing.js", "({ total: null, name: 'x' })")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	var optional struct {
+		Total int32  `json:"total,omitempty"`
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	optional.Total = 5
+	optional.Count = 3
+
+	assert.NoError(t, res.ToObject(&optional))
+	assert.Equal(t, int32(5), optional.Total)
+	assert.Equal(t, "x", optional.Name)
+	assert.Equal(t, 3, optional.Count)
+
+	var required struct {
+		Total int32  `json:"total"`
+		Name  string `json:"name"`
+	}
+
+	assert.Error(t, res.ToObject(&required))
+}
+
+func TestDecodeCycle(t *testing.T) {
+	res, err := runScript("decode_cycle.js", "const a = { name: 'a' }; a.next = a; a.extra = a; a")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	type node struct {
+		Name string `json:"name"`
+		Next *node  `json:"next"`
+	}
+
+	var list node
+
+	err = res.ToObject(&list, engines.IgnoreUnknownKeys())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "may be cyclic")
+
+	var loose struct {
+		Extra interface{} `json:"extra"`
+	}
+
+	err = res.ToObject(&loose, engines.IgnoreUnknownKeys())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "may be cyclic")
+}
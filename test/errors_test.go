@@ -0,0 +1,94 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mtrempoltsev/gojs/engines"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSError(t *testing.T) {
+	_, err := runScript("errors.js", "function f() {\n  null.x;\n}\nf();")
+
+	assert.Error(t, err)
+
+	var jsErr *engines.JSError
+
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("%T is not *engines.JSError", err)
+	}
+
+	assert.Equal(t, "TypeError", jsErr.Name)
+	assert.Equal(t, "errors.js", jsErr.File)
+	assert.Equal(t, 2, jsErr.Line)
+	assert.Equal(t, "  null.x;", jsErr.SourceLine)
+	assert.True(t, jsErr.Column > 0)
+	assert.NotEmpty(t, jsErr.Stack)
+
+	if len(jsErr.Stack) > 0 {
+		assert.Equal(t, "f", jsErr.Stack[0].Function)
+		assert.Equal(t, "errors.js", jsErr.Stack[0].File)
+		assert.Equal(t, 2, jsErr.Stack[0].Line)
+	}
+
+	err = _jsExecutor.Compile("errors.js", "let = ;")
+
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("%T is not *engines.JSError", err)
+	}
+
+	assert.Equal(t, "SyntaxError", jsErr.Name)
+	assert.Equal(t, 1, jsErr.Line)
+
+	_, err = runScript("errors.js", "throw 'timeout: 5s'")
+
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("%T is not *engines.JSError", err)
+	}
+
+	assert.Equal(t, "", jsErr.Name)
+	assert.Equal(t, "timeout: 5s", jsErr.Message)
+
+	err = _jsExecutor.RegisterFunc("failWith", func(msg string) error {
+		return errors.New(msg)
+	})
+
+	assert.NoError(t, err)
+
+	_, err = runScript("errors.js", "failWith('boom')")
+
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("%T is not *engines.JSError", err)
+	}
+
+	assert.NotEqual(t, "failWith", jsErr.Name)
+	assert.Equal(t, "failWith: boom", jsErr.Message)
+}
+
+func TestJSErrorFormat(t *testing.T) {
+	err := &engines.JSError{
+		Name:       "TypeError",
+		Message:    "x is not a function",
+		File:       "my.js",
+		Line:       3,
+		Column:     5,
+		EndColumn:  8,
+		SourceLine: "    x();",
+		Stack: []engines.StackFrame{
+			{Function: "f", File: "my.js", Line: 3, Column: 5},
+			{File: "my.js", Line: 5, Column: 1},
+		},
+	}
+
+	assert.Equal(t, "my.js:3: TypeError: x is not a function\n"+
+		"    x();\n"+
+		"    ^^^\n"+
+		"stack trace:\n"+
+		"    at f (my.js:3:5)\n"+
+		"    at my.js:5:1", err.Error())
+
+	err = &engines.JSError{Message: "something went wrong"}
+
+	assert.Equal(t, "something went wrong", err.Error())
+}
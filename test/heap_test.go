@@ -0,0 +1,56 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeapLimit(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithHeapLimits(16<<20, 0))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	err = js.RegisterFunc("answer", func() int { return 42 })
+
+	assert.NoError(t, err)
+
+	err = js.Compile("oom.js", "const a = []; while (true) { a.push(new Array(1000).fill('x')); }")
+
+	assert.NoError(t, err)
+
+	err = js.Compile("ok.js", "answer()")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	_, err = js.Run("oom.js")
+
+	assert.Equal(t, gojs.ErrHeapLimit, err)
+
+	// the runner is replaced, scripts and functions are still available
+	res, err := js.Run("ok.js")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	val, err := res.ToInt()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), val)
+}
@@ -0,0 +1,88 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsolation(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithIsolation())
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	const code = "globalThis.counter = (globalThis.counter || 0) + 1;" +
+		"function get() { return counter; }" +
+		"counter"
+
+	assert.NoError(t, js.Compile("isolated.js", code))
+
+	for i := 0; i < 4; i++ {
+		res, err := js.Run("isolated.js")
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		val, err := res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), val)
+
+		res, err = js.Call("isolated.js", "get")
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		val, err = res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), val)
+	}
+
+	assert.Error(t, js.CompileModule("isolated.mjs", "export const x = 1;"))
+}
+
+func TestNoIsolation(t *testing.T) {
+	js, err := gojs.New(1)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("shared.js", "globalThis.counter = (globalThis.counter || 0) + 1; counter"))
+
+	for i := 1; i <= 4; i++ {
+		res, err := js.Run("shared.js")
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		val, err := res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i), val)
+	}
+}
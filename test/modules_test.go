@@ -0,0 +1,68 @@
+package test
+
+import (
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapLoader map[string]string
+
+func (loader mapLoader) Resolve(specifier, referrer string) (string, string, error) {
+	id := path.Join(path.Dir(referrer), specifier)
+
+	source, ok := loader[id]
+	if !ok {
+		return "", "", fmt.Errorf("module %q not found", id)
+	}
+
+	return id, source, nil
+}
+
+func TestModules(t *testing.T) {
+	js, err := gojs.New(2, gojs.WithModuleLoader(mapLoader{
+		"lib/math.js": "import { twice } from './util.js'; export function add(a, b) { return twice(a) + b; }",
+		"lib/util.js": "export let calls = 0; export function twice(x) { calls++; return x * 2; }",
+	}))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	err = js.CompileModule("main.js",
+		"import { add } from './lib/math.js';"+
+			"export function transform(x) { return add(x, 1); }")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	for i := 0; i < 4; i++ {
+		res, err := js.Call("main.js", "transform", i)
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		val, err := res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i*2+1), val)
+	}
+
+	err = js.CompileModule("broken.js", "import { nothing } from './missing.js';")
+
+	assert.Error(t, err)
+}
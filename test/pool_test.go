@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/mtrempoltsev/gojs/engines"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynamicPool(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithDynamicPool(4, 100*time.Millisecond))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("busy.js", "var end = Date.now() + 50; while (Date.now() < end) {} 42"))
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := js.Run("busy.js")
+			if assert.NoError(t, err) {
+				val, err := res.ToInt()
+				res.Dispose()
+
+				assert.NoError(t, err)
+				assert.Equal(t, int64(42), val)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	stats := js.Stats()
+	assert.True(t, len(stats) > 1)
+	assert.True(t, len(stats) <= 4)
+
+	for _, s := range stats {
+		assert.True(t, s.Runner < 4)
+	}
+
+	// the runners added to handle the burst are removed when they are idle
+	assert.Eventually(t, func() bool {
+		return len(js.Stats()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+
+	res, err := js.Run("busy.js")
+	assert.NoError(t, err)
+
+	if err == nil {
+		res.Dispose()
+	}
+}
+
+func TestDynamicPoolKeepsResults(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithDynamicPool(4, 50*time.Millisecond))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("busy_obj.js",
+		"var end = Date.now() + 50; while (Date.now() < end) {} ({answer: 42, tags: ['a', 'b']})"))
+
+	var wg sync.WaitGroup
+
+	results := make([]gojs.ResultChannel, 8)
+
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			future, err := js.RunAsync("busy_obj.js")
+			assert.NoError(t, err)
+			results[i] = future
+		}(i)
+	}
+
+	wg.Wait()
+
+	var values []engines.Value
+
+	for _, future := range results {
+		if future == nil {
+			continue
+		}
+
+		res := <-future
+		if assert.NoError(t, res.Err) {
+			values = append(values, res.Val)
+		}
+	}
+
+	// the runners that made the values are removed, but the values stay valid
+	assert.Eventually(t, func() bool {
+		return len(js.Stats()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+
+	for _, val := range values {
+		var obj struct {
+			Answer int      `json:"answer"`
+			Tags   []string `json:"tags"`
+		}
+
+		assert.NoError(t, val.ToObject(&obj))
+		assert.Equal(t, 42, obj.Answer)
+		assert.Equal(t, []string{"a", "b"}, obj.Tags)
+
+		val.Dispose()
+	}
+}
+
+func TestDynamicPoolTryTasks(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithDynamicPool(2, time.Second))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("busy.js", "var end = Date.now() + 200; while (Date.now() < end) {} 42"))
+
+	first, err := js.RunAsync("busy.js")
+	assert.NoError(t, err)
+
+	// without a queue the task is rejected while the only runner is busy,
+	// but the pool grows for the next one
+	assert.Eventually(t, func() bool {
+		future, err := js.TryRunAsync(context.Background(), "busy.js")
+		if err != nil {
+			return false
+		}
+
+		res := <-future
+		if res.Err == nil {
+			res.Val.Dispose()
+		}
+
+		return res.Err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 2, len(js.Stats()))
+
+	if first != nil {
+		res := <-first
+		if assert.NoError(t, res.Err) {
+			res.Val.Dispose()
+		}
+	}
+}
+
+func TestDynamicPoolErrors(t *testing.T) {
+	_, err := gojs.New(4, gojs.WithDynamicPool(2, time.Second))
+	assert.Error(t, err)
+}
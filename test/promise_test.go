@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mtrempoltsev/gojs/engines"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromise(t *testing.T) {
+	res, err := runScript("promise.js", "(async () => 42)()")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	assert.True(t, res.IsPromise())
+	assert.False(t, res.IsNumber())
+
+	state, err := res.PromiseState()
+
+	assert.NoError(t, err)
+	assert.Equal(t, engines.PromiseFulfilled, state)
+
+	val, err := res.PromiseResult()
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer val.Dispose()
+
+	i, err := val.ToInt()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), i)
+}
+
+func TestRunAwait(t *testing.T) {
+	err := _jsExecutor.Compile("await.js",
+		"async function double(x) { return await Promise.resolve(x * 2); }")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	res, err := _jsExecutor.CallAwait(context.Background(), "await.js", "double", 21)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	i, err := res.ToInt()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), i)
+
+	err = _jsExecutor.Compile("await.js", "Promise.reject(new RangeError('too far'))")
+
+	assert.NoError(t, err)
+
+	_, err = _jsExecutor.RunAwait(context.Background(), "await.js")
+
+	var jsErr *engines.JSError
+
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("%T is not *engines.JSError", err)
+	}
+
+	assert.Equal(t, "RangeError", jsErr.Name)
+	assert.Equal(t, "too far", jsErr.Message)
+
+	err = _jsExecutor.Compile("await.js", "new Promise(() => {})")
+
+	assert.NoError(t, err)
+
+	_, err = _jsExecutor.RunAwait(context.Background(), "await.js")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithQueueSize(1))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("slow.js", "var end = Date.now() + 200; while (Date.now() < end) {} 1"))
+
+	first, err := js.RunAsync("slow.js")
+	assert.NoError(t, err)
+
+	// wait until the runner takes the first task
+	assert.Eventually(t, func() bool {
+		return js.QueueStats().Length == 0
+	}, time.Second, time.Millisecond)
+
+	second, err := js.TryRunAsync(context.Background(), "slow.js")
+	assert.NoError(t, err)
+
+	_, err = js.TryRunAsync(context.Background(), "slow.js")
+	assert.Equal(t, gojs.ErrQueueFull, err)
+
+	_, err = js.TryCallAsync(context.Background(), "slow.js", "f")
+	assert.Equal(t, gojs.ErrQueueFull, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = js.RunContext(ctx, "slow.js")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	for _, future := range []gojs.ResultChannel{first, second} {
+		if future == nil {
+			continue
+		}
+
+		res := <-future
+
+		assert.NoError(t, res.Err)
+
+		if res.Err == nil {
+			res.Val.Dispose()
+		}
+	}
+
+	stats := js.QueueStats()
+
+	assert.Equal(t, 1, stats.Capacity)
+	assert.Equal(t, uint64(2), stats.RejectedFull)
+	assert.Equal(t, uint64(1), stats.RejectedExpired)
+}
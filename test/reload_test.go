@@ -0,0 +1,111 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReload(t *testing.T) {
+	js, err := gojs.New(2)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("plugin.js", "function version() { return 1; }"))
+	assert.NoError(t, js.Compile("other.js", "1"))
+	assert.Equal(t, []string{"other.js", "plugin.js"}, js.Scripts())
+
+	call := func() int64 {
+		res, err := js.Call("plugin.js", "version")
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		defer res.Dispose()
+
+		val, err := res.ToInt()
+		assert.NoError(t, err)
+
+		return val
+	}
+
+	assert.Equal(t, int64(1), call())
+
+	assert.NoError(t, js.Replace("plugin.js", "function version() { return 2; }"))
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, int64(2), call())
+	}
+
+	assert.Error(t, js.Replace("plugin.js", "function version() {"))
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, int64(2), call())
+	}
+
+	assert.NoError(t, js.Compile("plugin.js", "function version() { return 3; }"))
+	assert.Equal(t, int64(3), call())
+
+	assert.Error(t, js.Replace("missing.js", "1"))
+
+	assert.NoError(t, js.Remove("plugin.js"))
+	assert.Equal(t, []string{"other.js"}, js.Scripts())
+	assert.Error(t, js.Remove("plugin.js"))
+
+	_, err = js.Call("plugin.js", "version")
+	assert.Error(t, err)
+
+	assert.Error(t, js.Replace("plugin.js", "function version() { return 4; }"))
+	assert.Equal(t, []string{"other.js"}, js.Scripts())
+}
+
+func TestReloadModuleDependencies(t *testing.T) {
+	loader := mapLoader{
+		"lib/version.js": "export const version = 1;",
+	}
+
+	js, err := gojs.New(2, gojs.WithModuleLoader(loader))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	code := "import { version } from './lib/version.js'; export function get() { return version; }"
+
+	assert.NoError(t, js.CompileModule("plugin.js", code))
+
+	call := func() int64 {
+		res, err := js.Call("plugin.js", "get")
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		defer res.Dispose()
+
+		val, err := res.ToInt()
+		assert.NoError(t, err)
+
+		return val
+	}
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, int64(1), call())
+	}
+
+	loader["lib/version.js"] = "export const version = 2;"
+
+	assert.NoError(t, js.Replace("plugin.js", code))
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, int64(2), call())
+	}
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequire(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/a.js": {Data: []byte(
+			"exports.name = 'a';" +
+				"const b = require('./b');" +
+				"exports.fromB = b.seenA;")},
+		"lib/b.js": {Data: []byte(
+			"const a = require('./a.js');" +
+				"module.exports = { seenA: a.name + ':' + (a.fromB === undefined) };")},
+		"lib/config.json":               {Data: []byte(`{"answer": 42}`)},
+		"node_modules/pkg/package.json": {Data: []byte(`{"main": "src/main.js"}`)},
+		"node_modules/pkg/src/main.js":  {Data: []byte("module.exports = (x) => x + __filename;")},
+	}
+
+	js, err := gojs.New(1, gojs.WithRequire(gojs.NewFSLoader(fsys)))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	err = js.Compile("main.js",
+		"const a = require('./lib/a');"+
+			"const config = require('./lib/config.json');"+
+			"const pkg = require('pkg');"+
+			"[a.fromB, config.answer, pkg('at '), require('./lib/a') === a].join(' ')")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	res, err := js.Run("main.js")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err := res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a:true 42 at node_modules/pkg/src/main.js true", str)
+
+	err = js.Compile("globals.js",
+		"[typeof __gojsResolveModule, typeof __gojsCompileModule, typeof __gojsCurrentScript].join(' ')")
+
+	assert.NoError(t, err)
+
+	res, err = js.Run("globals.js")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err = res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "undefined undefined undefined", str)
+
+	err = js.Compile("missing.js", "require('./missing')")
+
+	assert.NoError(t, err)
+
+	_, err = js.Run("missing.js")
+
+	assert.Error(t, err)
+}
+
+func TestFSLoader(t *testing.T) {
+	loader := gojs.NewFSLoader(fstest.MapFS{
+		"src/index.js":                  {Data: []byte("index")},
+		"src/util.js":                   {Data: []byte("util")},
+		"node_modules/dep/index.js":     {Data: []byte("dep")},
+		"src/node_modules/dep/index.js": {Data: []byte("nested dep")},
+	})
+
+	id, source, err := loader.Resolve("./util", "src/index.js")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "src/util.js", id)
+	assert.Equal(t, "util", source)
+
+	id, _, err = loader.Resolve("../src", "src/util.js")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "src/index.js", id)
+
+	id, source, err = loader.Resolve("dep", "src/util.js")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "src/node_modules/dep/index.js", id)
+	assert.Equal(t, "nested dep", source)
+
+	id, _, err = loader.Resolve("dep", "main.js")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "node_modules/dep/index.js", id)
+
+	_, _, err = loader.Resolve("./nothing", "main.js")
+
+	assert.Error(t, err)
+}
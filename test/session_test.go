@@ -0,0 +1,105 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	js, err := gojs.New(4)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("counter.js", "var total = 0; function add(x) { total += x; return total; }"))
+	assert.NoError(t, js.Compile("total.js", "total"))
+
+	first, err := js.NewSession()
+	assert.NoError(t, err)
+
+	second, err := js.NewSession()
+	assert.NoError(t, err)
+
+	call := func(session *gojs.Session, x int) int64 {
+		res, err := session.Call("counter.js", "add", x)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		defer res.Dispose()
+
+		val, err := res.ToInt()
+		assert.NoError(t, err)
+
+		return val
+	}
+
+	for i := 1; i <= 10; i++ {
+		assert.Equal(t, int64(i), call(first, 1))
+		assert.Equal(t, int64(i*2), call(second, 2))
+	}
+
+	// scripts of a session share its globals
+	res, err := first.Run("total.js")
+
+	assert.NoError(t, err)
+
+	if err == nil {
+		val, err := res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), val)
+	}
+
+	_, err = first.Run("missing.js")
+	assert.Error(t, err)
+
+	assert.NoError(t, first.Close())
+	assert.Equal(t, gojs.ErrSessionClosed, first.Close())
+
+	_, err = first.Call("counter.js", "add", 1)
+	assert.Equal(t, gojs.ErrSessionClosed, err)
+
+	assert.Equal(t, int64(22), call(second, 2))
+	assert.NoError(t, second.Close())
+}
+
+func TestSessionCloseAfterFailure(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithHeapLimits(16<<20, 0))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("oom.js", "const a = []; while (true) { a.push(new Array(1000).fill('x')); }"))
+
+	session, err := js.NewSession()
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	_, err = session.Run("oom.js")
+	assert.Equal(t, gojs.ErrHeapLimit, err)
+
+	// the context of the session has been disposed with the failed runner
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, session.CloseContext(ctx))
+	assert.Equal(t, gojs.ErrSessionClosed, session.Close())
+}
@@ -0,0 +1,171 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdown(t *testing.T) {
+	js, err := gojs.New(2)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	assert.NoError(t, js.Compile("slow.js", "var end = Date.now() + 100; while (Date.now() < end) {} 42"))
+
+	future, err := js.RunAsync("slow.js")
+	assert.NoError(t, err)
+
+	assert.NoError(t, js.Shutdown(context.Background()))
+
+	// accepted tasks are finished
+	res := <-future
+
+	assert.NoError(t, res.Err)
+
+	if res.Err == nil {
+		val, err := res.Val.ToInt()
+		res.Val.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), val)
+	}
+
+	_, err = js.Run("slow.js")
+	assert.Equal(t, gojs.ErrClosed, err)
+
+	_, err = js.Call("slow.js", "f")
+	assert.Equal(t, gojs.ErrClosed, err)
+
+	_, err = js.TryRunAsync(context.Background(), "slow.js")
+	assert.Equal(t, gojs.ErrClosed, err)
+
+	assert.Equal(t, gojs.ErrClosed, js.Compile("other.js", "1"))
+	assert.Equal(t, gojs.ErrClosed, js.Remove("slow.js"))
+	assert.Equal(t, gojs.ErrClosed, js.RegisterFunc("f", func() {}))
+
+	_, err = js.NewSession()
+	assert.Equal(t, gojs.ErrClosed, err)
+
+	assert.Equal(t, gojs.ErrClosed, js.Shutdown(context.Background()))
+
+	js.Dispose()
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithQueueSize(4))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	assert.NoError(t, js.Compile("endless.js", "while (true) {}"))
+
+	running, err := js.RunAsync("endless.js")
+	assert.NoError(t, err)
+
+	queued, err := js.RunAsync("endless.js")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, js.Shutdown(ctx))
+
+	res := <-running
+	assert.Error(t, res.Err)
+
+	res = <-queued
+	assert.Error(t, res.Err)
+
+	_, err = js.Run("endless.js")
+	assert.Equal(t, gojs.ErrClosed, err)
+}
+
+func TestShutdownBlockedCallback(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithQueueSize(4))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	unblock := make(chan struct{})
+	entered := make(chan struct{})
+
+	assert.NoError(t, js.RegisterFunc("block", func() {
+		close(entered)
+		<-unblock
+	}))
+
+	assert.NoError(t, js.Compile("blocked.js", "block(); 1"))
+	assert.NoError(t, js.Compile("queued.js", "2"))
+
+	running, err := js.RunAsync("blocked.js")
+	assert.NoError(t, err)
+
+	<-entered
+
+	queued, err := js.RunAsync("queued.js")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	assert.Equal(t, context.DeadlineExceeded, js.Shutdown(ctx))
+	assert.True(t, time.Since(start) < time.Second)
+
+	res := <-queued
+	assert.Equal(t, gojs.ErrClosed, res.Err)
+
+	close(unblock)
+
+	res = <-running
+	assert.Error(t, res.Err)
+}
+
+func TestDisposeWaitsForRunners(t *testing.T) {
+	js, err := gojs.New(2)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	entered := make(chan struct{})
+
+	assert.NoError(t, js.RegisterFunc("enter", func() {
+		close(entered)
+	}))
+
+	assert.NoError(t, js.Compile("entered.js", "enter(); while (true) {}"))
+
+	running, err := js.RunAsync("entered.js")
+	assert.NoError(t, err)
+
+	<-entered
+
+	js.Dispose()
+
+	// the runner has exited before Dispose returned, so the terminated task
+	// has its result already
+	select {
+	case res := <-running:
+		assert.Error(t, res.Err)
+	default:
+		assert.Fail(t, "Dispose returned before the runner had stopped")
+	}
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	snapshot, err := gojs.BuildSnapshot(
+		gojs.SnapshotScript{Name: "sdk.js", Code: "var sdk = { scale: 3 };"},
+		gojs.SnapshotScript{Name: "lib.js", Code: "function scaled(x) { return x * sdk.scale; }"},
+	)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, snapshot)
+
+	if err != nil {
+		return
+	}
+
+	js, err := gojs.New(2, gojs.WithSnapshot(snapshot))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("snapshot.js", "scaled(14)"))
+
+	for i := 0; i < 4; i++ {
+		res, err := js.Run("snapshot.js")
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		val, err := res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), val)
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	_, err := gojs.BuildSnapshot()
+	assert.Error(t, err)
+
+	_, err = gojs.BuildSnapshot(gojs.SnapshotScript{Name: "broken.js", Code: "var x = ;"})
+	assert.Error(t, err)
+}
+
+func TestSnapshotKeepsExecutors(t *testing.T) {
+	_, err := gojs.BuildSnapshot(gojs.SnapshotScript{Name: "boot.js", Code: "var ready = true;"})
+
+	assert.NoError(t, err)
+
+	res, err := runScript("after_snapshot.js", "6 * 7")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	val, err := res.ToInt()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), val)
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	js, err := gojs.New(2)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	err = js.Compile("stats.js", "new Array(1000).fill(0).length")
+
+	assert.NoError(t, err)
+
+	const tasks = 10
+
+	for i := 0; i < tasks; i++ {
+		res, err := js.Run("stats.js")
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		res.Dispose()
+	}
+
+	stats := js.Stats()
+
+	assert.Len(t, stats, 2)
+
+	var executed uint64
+
+	for i, s := range stats {
+		assert.Equal(t, i, s.Runner)
+		assert.Equal(t, 1, s.Scripts)
+		assert.True(t, s.Heap.UsedHeapSize > 0)
+		assert.True(t, s.Heap.HeapSizeLimit >= s.Heap.TotalHeapSize)
+		assert.Equal(t, s.TasksExecuted, s.ExecutionTime.Count)
+		assert.Equal(t, s.TasksExecuted, s.QueueWait.Count)
+		executed += s.TasksExecuted
+	}
+
+	assert.Equal(t, uint64(tasks), executed)
+
+	var buf bytes.Buffer
+
+	w := gojs.NewPrometheusWriter(&buf)
+
+	js.CollectMetrics(w)
+
+	assert.NoError(t, w.Err())
+	assert.Contains(t, buf.String(), "# TYPE gojs_tasks_total counter\n")
+	assert.Contains(t, buf.String(), "gojs_scripts{runner=\"1\"} 1\n")
+}
+
+func TestPrometheusWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := gojs.NewPrometheusWriter(&buf)
+
+	w.Gauge("heap", "Heap size.", gojs.Labels{"runner": "0"}, 1024)
+	w.Gauge("heap", "Heap size.", gojs.Labels{"runner": "1"}, 2048)
+	w.Histogram("latency", "Latency.", gojs.Labels{"runner": "0"}, gojs.Histogram{
+		Bounds: []float64{0.1, 1},
+		Counts: []uint64{2, 1, 1},
+		Count:  4,
+		Sum:    3.5,
+	})
+
+	assert.NoError(t, w.Err())
+	assert.Equal(t, "# HELP heap Heap size.\n"+
+		"# TYPE heap gauge\n"+
+		"heap{runner=\"0\"} 1024\n"+
+		"heap{runner=\"1\"} 2048\n"+
+		"# HELP latency Latency.\n"+
+		"# TYPE latency histogram\n"+
+		"latency_bucket{runner=\"0\",le=\"0.1\"} 2\n"+
+		"latency_bucket{runner=\"0\",le=\"1\"} 3\n"+
+		"latency_bucket{runner=\"0\",le=\"+Inf\"} 4\n"+
+		"latency_sum{runner=\"0\"} 3.5\n"+
+		"latency_count{runner=\"0\"} 4\n", buf.String())
+}
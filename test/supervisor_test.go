@@ -0,0 +1,146 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunnerRespawn(t *testing.T) {
+	events := make(chan gojs.RunnerEvent, 8)
+
+	js, err := gojs.New(2,
+		gojs.WithHeapLimits(16<<20, 0),
+		gojs.WithRunnerEvents(func(event gojs.RunnerEvent) {
+			events <- event
+		}))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	assert.NoError(t, js.Compile("oom.js", "const a = []; while (true) { a.push(new Array(1000).fill('x')); }"))
+	assert.NoError(t, js.Compile("ok.js", "40 + 2"))
+
+	_, err = js.Run("oom.js")
+	assert.Equal(t, gojs.ErrHeapLimit, err)
+
+	failed := <-events
+	assert.Equal(t, gojs.RunnerFailed, failed.Kind)
+	assert.Equal(t, gojs.ErrHeapLimit, failed.Err)
+
+	respawned := <-events
+	assert.Equal(t, gojs.RunnerRespawned, respawned.Kind)
+	assert.Equal(t, failed.Runner, respawned.Runner)
+	assert.NoError(t, respawned.Err)
+
+	health := js.Health()
+	assert.Equal(t, 2, health.Runners)
+	assert.Equal(t, uint64(1), health.Failures)
+	assert.Equal(t, uint64(1), health.Respawns)
+
+	// both runners have every script
+	for i := 0; i < 8; i++ {
+		res, err := js.Run("ok.js")
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		val, err := res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), val)
+	}
+}
+
+func TestRegisterFuncRespawn(t *testing.T) {
+	events := make(chan gojs.RunnerEvent, 8)
+
+	js, err := gojs.New(1,
+		gojs.WithDynamicPool(2, 0),
+		gojs.WithRunnerEvents(func(event gojs.RunnerEvent) {
+			events <- event
+		}))
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	unblock := make(chan struct{})
+	entered := make(chan struct{})
+
+	assert.NoError(t, js.RegisterFunc("block", func() {
+		close(entered)
+		<-unblock
+	}))
+
+	assert.NoError(t, js.Compile("blocked.js", "block(); 1"))
+	assert.NoError(t, js.Compile("global.js", "var ns = 1; ns"))
+	assert.NoError(t, js.Compile("call.js", "ns.twice(21)"))
+
+	blocked, err := js.RunAsync("blocked.js")
+	assert.NoError(t, err)
+
+	<-entered
+
+	// the first runner is busy, so the pool grows and only the second runner
+	// gets the global
+	res, err := js.Run("global.js")
+	assert.NoError(t, err)
+
+	if err == nil {
+		res.Dispose()
+	}
+
+	close(unblock)
+
+	if res := <-blocked; assert.NoError(t, res.Err) {
+		res.Val.Dispose()
+	}
+
+	// the name conflicts with the global only in the second runner, which
+	// is replaced
+	assert.NoError(t, js.RegisterFunc("ns.twice", func(x int) int {
+		return 2 * x
+	}))
+
+	failed := <-events
+	assert.Equal(t, gojs.RunnerFailed, failed.Kind)
+	assert.Equal(t, 1, failed.Runner)
+	assert.Error(t, failed.Err)
+
+	respawned := <-events
+	assert.Equal(t, gojs.RunnerRespawned, respawned.Kind)
+	assert.Equal(t, 1, respawned.Runner)
+
+	assert.Equal(t, 2, js.Health().Runners)
+
+	for i := 0; i < 8; i++ {
+		res, err := js.Run("call.js")
+
+		assert.NoError(t, err)
+
+		if err != nil {
+			return
+		}
+
+		val, err := res.ToInt()
+		res.Dispose()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), val)
+	}
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mtrempoltsev/gojs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimers(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithEventLoop())
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	err = js.Compile("timers.js",
+		"function order() {"+
+			"  return new Promise((resolve) => {"+
+			"    const log = [];"+
+			"    setTimeout((x) => log.push(x), 20, 'timeout');"+
+			"    const id = setTimeout(() => log.push('cleared'), 10);"+
+			"    clearTimeout(id);"+
+			"    let n = 0;"+
+			"    const interval = setInterval(() => {"+
+			"      log.push('interval');"+
+			"      if (++n == 2) clearInterval(interval);"+
+			"    }, 5);"+
+			"    queueMicrotask(() => log.push('microtask'));"+
+			"    setTimeout(() => resolve(log.join(',')), 30);"+
+			"  });"+
+			"}")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	res, err := js.CallAwait(context.Background(), "timers.js", "order")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err := res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "microtask,interval,interval,timeout", str)
+}
+
+func TestLeakedTimers(t *testing.T) {
+	js, err := gojs.New(1, gojs.WithEventLoop())
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer js.Dispose()
+
+	err = js.Compile("leak.js", "setInterval(() => {}, 1); setTimeout(() => {}, 100000)")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = js.RunContext(ctx, "leak.js")
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Contains(t, err.Error(), "2 timers leaked")
+}
@@ -1,8 +1,12 @@
 package test
 
 import (
-	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
+	"time"
+
+	"github.com/mtrempoltsev/gojs/engines"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestUndefined(t *testing.T) {
@@ -701,3 +705,441 @@ func TestStringArray(t *testing.T) {
 
 func TestNotImplemented(t *testing.T) {
 }
+
+func TestArray(t *testing.T) {
+	res, err := runScript("my.js", "[1, 0.5, 'a', true, null, [2], { x: 3 }]")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	arr, err := res.ToArray()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		int64(1), 0.5, "a", true, nil, []interface{}{int64(2)}, map[string]interface{}{"x": int64(3)},
+	}, arr)
+
+	res, err = runScript("my.js", "1")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	_, err = res.ToArray()
+
+	assert.Error(t, err)
+	assert.Equal(t, "Can't convert number to []interface{}", err.Error())
+}
+
+func TestInterface(t *testing.T) {
+	res, err := runScript("my.js",
+		"({ n: 12345678901234567890123n, neg: -5n, d: new Date(Date.UTC(2020, 0, 2, 3, 4, 5, 6)), s: 'x', u: undefined })")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	val, err := res.ToInterface()
+
+	assert.NoError(t, err)
+
+	obj, ok := val.(map[string]interface{})
+
+	assert.True(t, ok)
+
+	if !ok {
+		return
+	}
+
+	n, _ := new(big.Int).SetString("12345678901234567890123", 10)
+
+	assert.Equal(t, 0, n.Cmp(obj["n"].(*big.Int)))
+	assert.Equal(t, 0, big.NewInt(-5).Cmp(obj["neg"].(*big.Int)))
+	assert.True(t, time.Date(2020, 1, 2, 3, 4, 5, 6e6, time.UTC).Equal(obj["d"].(time.Time)))
+	assert.Equal(t, "x", obj["s"])
+	assert.Nil(t, obj["u"])
+
+	res, err = runScript("my.js", "Symbol('s')")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	_, err = res.ToInterface()
+
+	assert.Error(t, err)
+
+	res, err = runScript("my.js",
+		"({ f() {}, s: Symbol('s'), [Symbol('k')]: 1, list: [1, () => 2, 3], m: new Map([['g', Math.max], ['x', 1]]) })")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	val, err = res.ToInterface()
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"list": []interface{}{int64(1), nil, int64(3)},
+		"m":    map[interface{}]interface{}{"x": int64(1)},
+	}, val)
+}
+
+func TestMap(t *testing.T) {
+	res, err := runScript("my.js", "new Map([['b', 1], [2, 'two'], ['a', [3]]])")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	assert.True(t, res.IsMap())
+
+	entries, err := res.ToMap()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []engines.MapEntry{
+		{Key: "b", Value: int64(1)},
+		{Key: int64(2), Value: "two"},
+		{Key: "a", Value: []interface{}{int64(3)}},
+	}, entries)
+
+	_, err = res.ToStringMap()
+	assert.Error(t, err)
+
+	res, err = runScript("my.js", "new Map([['x', 'a'], ['y', 'b']])")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	dict, err := res.ToStringMap()
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"x": "a", "y": "b"}, dict)
+
+	res, err = runScript("my.js", "({ lookup: new Map([[1, 'one'], [20, 'twenty']]), ids: new Set([3, 1]) })")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	var obj struct {
+		Lookup map[int]string `json:"lookup"`
+		IDs    []int          `json:"ids"`
+	}
+
+	assert.NoError(t, res.ToObject(&obj))
+	assert.Equal(t, map[int]string{1: "one", 20: "twenty"}, obj.Lookup)
+	assert.Equal(t, []int{3, 1}, obj.IDs)
+
+	_, err = res.ToMap()
+
+	assert.Error(t, err)
+	assert.Equal(t, "Can't convert object to map", err.Error())
+}
+
+func TestSet(t *testing.T) {
+	res, err := runScript("my.js", "new Set(['a', 1, 'a', true])")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	assert.True(t, res.IsSet())
+
+	elems, err := res.ToSet()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", int64(1), true}, elems)
+
+	val, err := res.ToInterface()
+
+	assert.NoError(t, err)
+	assert.Equal(t, elems, val)
+
+	res, err = runScript("my.js", "[1]")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	_, err = res.ToSet()
+
+	assert.Error(t, err)
+	assert.Equal(t, "Can't convert array to set", err.Error())
+}
+
+func TestBigInt(t *testing.T) {
+	res, err := runScript("my.js", "-(2n ** 100n)")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	assert.True(t, res.IsBigInt())
+	assert.False(t, res.IsNumber())
+
+	val, err := res.ToBigInt()
+
+	expected := new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 100))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, expected.Cmp(val))
+
+	res, err = runScript("my.js", "1")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	_, err = res.ToBigInt()
+
+	assert.Error(t, err)
+}
+
+func TestDate(t *testing.T) {
+	res, err := runScript("my.js", "new Date(Date.UTC(2020, 1, 29, 12, 30, 15, 250))")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	assert.True(t, res.IsDate())
+	assert.True(t, res.IsObject())
+
+	val, err := res.ToTime()
+
+	expected := time.Date(2020, time.February, 29, 12, 30, 15, 250*int(time.Millisecond), time.UTC)
+
+	assert.NoError(t, err)
+	assert.True(t, expected.Equal(val))
+
+	res, err = runScript("my.js", "'2020-02-29'")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	_, err = res.ToTime()
+
+	assert.Error(t, err)
+}
+
+func TestSymbol(t *testing.T) {
+	res, err := runScript("my.js", "Symbol('token')")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	assert.True(t, res.IsSymbol())
+
+	desc, err := res.SymbolDescription()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "token", desc)
+
+	res, err = runScript("my.js", "Symbol()")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	desc, err = res.SymbolDescription()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", desc)
+
+	res, err = runScript("my.js", "'token'")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	assert.False(t, res.IsSymbol())
+
+	_, err = res.SymbolDescription()
+
+	assert.Error(t, err)
+}
+
+func TestPassBigIntAndDate(t *testing.T) {
+	err := _jsExecutor.Compile("big_int_date.js",
+		"function describe(n, d) {"+
+			"  return [typeof n, n.toString(), d instanceof Date, d.toISOString()].join(':');"+
+			"}"+
+			"function next(e) {"+
+			"  return { count: e.count + 1n, at: new Date(e.at.getTime() + 1000) };"+
+			"}")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	n, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	d := time.Date(2021, time.March, 1, 8, 0, 0, 500*int(time.Millisecond), time.UTC)
+
+	res, err := _jsExecutor.Call("big_int_date.js", "describe", n, d)
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	str, err := res.ToString()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "bigint:-123456789012345678901234567890:true:2021-03-01T08:00:00.500Z", str)
+
+	type event struct {
+		Count *big.Int  `json:"count"`
+		At    time.Time `json:"at"`
+	}
+
+	res, err = _jsExecutor.Call("big_int_date.js", "next", event{Count: n, At: d})
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	var e event
+
+	assert.NoError(t, res.ToObject(&e))
+	assert.Equal(t, "-123456789012345678901234567889", e.Count.String())
+	assert.True(t, d.Add(time.Second).Equal(e.At))
+}
+
+func TestDateMilliseconds(t *testing.T) {
+	base := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	res, err := runScript("my.js",
+		"Array.from({ length: 1000 }, (_, i) => new Date(Date.UTC(2020, 0, 2, 3, 4, 5, i)))")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	dates, err := res.ToArray()
+
+	assert.NoError(t, err)
+
+	for i, date := range dates {
+		expected := base.Add(time.Duration(i) * time.Millisecond)
+		if !assert.True(t, expected.Equal(date.(time.Time)), "%v != %v", expected, date) {
+			return
+		}
+	}
+}
+
+func TestInterfaceCycle(t *testing.T) {
+	res, err := runScript("my.js", "const a = { list: [] }; a.list.push(a); a")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	_, err = res.ToInterface()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "may be cyclic")
+
+	res, err = runScript("my.js", "const m = new Map(); m.set('self', m); m")
+
+	assert.NoError(t, err)
+
+	if err != nil {
+		return
+	}
+
+	defer res.Dispose()
+
+	_, err = res.ToMap()
+
+	assert.Error(t, err)
+}